@@ -2,10 +2,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime/debug"
 	"strings"
+	"syscall"
 
 	"github.com/AndreyAkinshin/herald/internal/cli"
 	"github.com/AndreyAkinshin/herald/internal/errors"
@@ -35,7 +38,17 @@ func main() {
 		return
 	}
 
-	if err := cli.Run(cfg); err != nil {
+	// The first Ctrl-C or SIGTERM cancels the run, stopping running commands;
+	// a second one exits right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := cli.Run(ctx, cfg); err != nil {
 		handleError(err)
 	}
 }
@@ -0,0 +1,299 @@
+// Package cache keeps the per-commit summaries of the model on disk, so that commits
+// summarized once are not sent again, neither by later runs nor for later releases.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// Key identifies a summary: the commit, the model that wrote it, the version of the
+// prompt it was asked with and the diffs the prompt included. A different model, prompt
+// or diff setting gives a different summary.
+type Key struct {
+	Commit        string
+	Model         string
+	PromptVersion int
+	// Diffs describes the patches embedded in the prompt, such as "small:2048:65536",
+	// or is empty for none.
+	Diffs string
+}
+
+// Entry is a cached summary.
+type Entry struct {
+	Commit        string    `json:"commit"`
+	Model         string    `json:"model"`
+	PromptVersion int       `json:"promptVersion"`
+	Diffs         string    `json:"diffs,omitempty"`
+	Summary       string    `json:"summary"`
+	Created       time.Time `json:"created"`
+}
+
+// Store keeps one JSON file per entry, grouped into directories by the first two
+// characters of the commit hash. The modification time of a file is when it was last used.
+type Store struct {
+	dir string
+}
+
+// Open returns the store under DefaultDir.
+func Open() (*Store, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(dir), nil
+}
+
+// New returns a store that keeps its files in dir.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns $XDG_CACHE_HOME/herald/summaries, or ~/.cache/herald/summaries if unset.
+func DefaultDir() (string, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Environment("cannot determine the cache directory", err)
+		}
+
+		dir = filepath.Join(home, ".cache")
+	}
+
+	return filepath.Join(dir, "herald", "summaries"), nil
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns the cached summary for k and marks it as used. A missing or unreadable
+// entry is a miss.
+func (s *Store) Get(k Key) (string, bool) {
+	path := s.path(k)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	var e Entry
+	if json.Unmarshal(data, &e) != nil || e.Commit != k.Commit {
+		return "", false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return e.Summary, true
+}
+
+// Put stores the summary for k. The file is replaced atomically, so concurrent runs
+// never read a partial entry.
+func (s *Store) Put(k Key, summary string) error {
+	data, err := json.MarshalIndent(Entry{
+		Commit:        k.Commit,
+		Model:         k.Model,
+		PromptVersion: k.PromptVersion,
+		Diffs:         k.Diffs,
+		Summary:       summary,
+		Created:       time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return errors.Runtime("failed to encode cache entry", err)
+	}
+
+	path := s.path(k)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Runtime("failed to create cache directory", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), tempPrefix+"*")
+	if err != nil {
+		return errors.Runtime("failed to write cache entry", err)
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return errors.Runtime("failed to write cache entry", err)
+	}
+
+	return nil
+}
+
+// Stats describes the contents of the store.
+type Stats struct {
+	Entries int
+	// Bytes is the total size of the entry files.
+	Bytes int64
+	// Models counts the entries of each model.
+	Models map[string]int
+	// Stale counts the entries written with another prompt version than the current one.
+	Stale int
+	// Oldest and Newest are the earliest and latest times an entry was used.
+	Oldest, Newest time.Time
+}
+
+// Stats scans the store; promptVersion is the current prompt version, to count stale entries.
+func (s *Store) Stats(promptVersion int) (Stats, error) {
+	st := Stats{Models: map[string]int{}}
+
+	err := s.walk(func(_ string, info fs.FileInfo, e *Entry) error {
+		if e == nil {
+			return nil
+		}
+
+		st.Entries++
+		st.Bytes += info.Size()
+		st.Models[e.Model]++
+
+		if e.PromptVersion != promptVersion {
+			st.Stale++
+		}
+
+		used := info.ModTime()
+		if st.Oldest.IsZero() || used.Before(st.Oldest) {
+			st.Oldest = used
+		}
+
+		if used.After(st.Newest) {
+			st.Newest = used
+		}
+
+		return nil
+	})
+
+	return st, err
+}
+
+// tempPrefix starts the names of the temporary files of Put.
+const tempPrefix = ".tmp-"
+
+// tempAge is the age after which a temporary file of Put is left over from an interrupted
+// run rather than being written.
+const tempAge = time.Hour
+
+// Prune removes the entries not used within maxAge, as well as those written with
+// another prompt version than promptVersion, which are never used again, and the
+// temporary files left by interrupted runs. It returns the number of entries removed.
+func (s *Store) Prune(maxAge time.Duration, promptVersion int) (int, error) {
+	now := time.Now()
+	cutoff := now.Add(-maxAge)
+	removed := 0
+
+	err := s.walk(func(path string, info fs.FileInfo, e *Entry) error {
+		if isTemp(path) {
+			if now.Sub(info.ModTime()) < tempAge {
+				return nil
+			}
+
+			if err := os.Remove(path); err != nil {
+				return errors.Runtime("failed to remove temporary cache file", err)
+			}
+
+			return nil
+		}
+
+		if e != nil && e.PromptVersion == promptVersion && !info.ModTime().Before(cutoff) {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return errors.Runtime("failed to remove cache entry", err)
+		}
+
+		removed++
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// Clear removes every entry.
+func (s *Store) Clear() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return errors.Runtime("failed to clear cache", err)
+	}
+
+	return nil
+}
+
+// walk calls fn for every entry file and temporary file of Put. Temporary files and files
+// that cannot be parsed, such as ones left by an older herald, are passed with a nil entry.
+func (s *Store) walk(fn func(path string, info fs.FileInfo, e *Entry) error) error {
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == s.dir {
+				return filepath.SkipDir
+			}
+
+			return err
+		}
+
+		if d.IsDir() || (filepath.Ext(path) != ".json" && !isTemp(path)) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if isTemp(path) {
+			return fn(path, info, nil)
+		}
+
+		var e *Entry
+
+		if data, err := os.ReadFile(path); err == nil {
+			var parsed Entry
+			if json.Unmarshal(data, &parsed) == nil {
+				e = &parsed
+			}
+		}
+
+		return fn(path, info, e)
+	})
+	if err != nil {
+		return errors.Runtime("failed to read cache "+s.dir, err)
+	}
+
+	return nil
+}
+
+// isTemp reports whether path is a temporary file of Put.
+func isTemp(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), tempPrefix)
+}
+
+// path returns the file of an entry: the commit hash and a hash of the model, the prompt
+// version and the diffs, which may contain any characters.
+func (s *Store) path(k Key) string {
+	sum := sha256.Sum256([]byte(k.Model + "\x00" + strconv.Itoa(k.PromptVersion) + "\x00" + k.Diffs))
+	name := k.Commit + "-" + hex.EncodeToString(sum[:6]) + ".json"
+
+	return filepath.Join(s.dir, k.Commit[:min(len(k.Commit), 2)], name)
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const hash = "82c576e0f3910b38510400dd3c9b0200ee974084"
+
+func TestStore_put_and_get(t *testing.T) {
+	s := New(t.TempDir())
+	k := Key{Commit: hash, Model: "claude/opus", PromptVersion: 1}
+
+	if _, ok := s.Get(k); ok {
+		t.Fatal("expected a miss in an empty cache")
+	}
+
+	if err := s.Put(k, "internal: rename flag"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	got, ok := s.Get(k)
+	if !ok || got != "internal: rename flag" {
+		t.Errorf("got %q, %v", got, ok)
+	}
+
+	// Another model, prompt version or diff setting is another entry
+	for _, other := range []Key{
+		{Commit: hash, Model: "claude/haiku", PromptVersion: 1},
+		{Commit: hash, Model: "claude/opus", PromptVersion: 2},
+		{Commit: hash, Model: "claude/opus", PromptVersion: 1, Diffs: "small:2048:65536"},
+	} {
+		if _, ok := s.Get(other); ok {
+			t.Errorf("unexpected hit for %+v", other)
+		}
+	}
+}
+
+func TestStore_stats(t *testing.T) {
+	s := New(t.TempDir())
+
+	for _, k := range []Key{
+		{Commit: hash, Model: "claude/opus", PromptVersion: 1},
+		{Commit: "abc123", Model: "claude/opus", PromptVersion: 1},
+		{Commit: "def456", Model: "anthropic", PromptVersion: 0},
+	} {
+		if err := s.Put(k, "summary"); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+
+	st, err := s.Stats(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if st.Entries != 3 || st.Stale != 1 || st.Models["claude/opus"] != 2 || st.Bytes == 0 {
+		t.Errorf("got %+v", st)
+	}
+}
+
+func TestStore_stats_missing_dir(t *testing.T) {
+	st, err := New(filepath.Join(t.TempDir(), "none")).Stats(1)
+	if err != nil || st.Entries != 0 {
+		t.Errorf("got %+v, %v", st, err)
+	}
+}
+
+func TestStore_prune(t *testing.T) {
+	s := New(t.TempDir())
+
+	fresh := Key{Commit: hash, Model: "claude", PromptVersion: 1}
+	old := Key{Commit: "abc123", Model: "claude", PromptVersion: 1}
+	stale := Key{Commit: "def456", Model: "claude", PromptVersion: 0}
+
+	for _, k := range []Key{fresh, old, stale} {
+		if err := s.Put(k, "summary"); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+
+	lastMonth := time.Now().Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(s.path(old), lastMonth, lastMonth); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := s.Prune(7*24*time.Hour, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if removed != 2 {
+		t.Errorf("removed %d entries, want 2", removed)
+	}
+
+	if _, ok := s.Get(fresh); !ok {
+		t.Error("fresh entry was pruned")
+	}
+}
+
+func TestStore_prune_temporary_files(t *testing.T) {
+	s := New(t.TempDir())
+	dir := filepath.Join(s.Dir(), "ab")
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	leftover, writing := filepath.Join(dir, ".tmp-1"), filepath.Join(dir, ".tmp-2")
+	for _, path := range []string{leftover, writing} {
+		if err := os.WriteFile(path, []byte(`{"commit":`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(leftover, yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := s.Prune(7*24*time.Hour, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if removed != 0 {
+		t.Errorf("removed %d entries, want 0", removed)
+	}
+
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Error("temporary file of an interrupted run was kept")
+	}
+
+	if _, err := os.Stat(writing); err != nil {
+		t.Errorf("temporary file being written was removed: %v", err)
+	}
+
+	if st, err := s.Stats(1); err != nil || st.Entries != 0 {
+		t.Errorf("stats = %+v, %v; temporary files are not entries", st, err)
+	}
+}
+
+func TestStore_clear(t *testing.T) {
+	s := New(t.TempDir())
+	k := Key{Commit: hash, Model: "claude", PromptVersion: 1}
+
+	if err := s.Put(k, "summary"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := s.Get(k); ok {
+		t.Error("entry survived clear")
+	}
+}
@@ -0,0 +1,355 @@
+// Package changelog maintains a Keep a Changelog style CHANGELOG.md file.
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/semver"
+)
+
+// Default formats. Placeholders: {tag}, {version} (tag without a leading "v"),
+// {date} (YYYY-MM-DD) and, for links, {url}.
+const (
+	DefaultHeading = "## [{version}] - {date}"
+	DefaultLink    = "[{version}]: {url}"
+)
+
+// defaultHeader starts a newly created changelog.
+const defaultHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// versionRe matches a version within a heading, e.g. "v1.2.0" in "## Release v1.2.0 (2025-01-01)".
+var versionRe = regexp.MustCompile(`\bv?\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`)
+
+// placeholderRe matches the placeholders of a heading format.
+var placeholderRe = regexp.MustCompile(`\{(?:tag|version|date|url)\}`)
+
+// linkDefRe matches a markdown link reference definition, e.g. "[1.0.0]: https://...".
+var linkDefRe = regexp.MustCompile(`^\[([^\]]+)\]:\s`)
+
+// Entry is the section to insert for one release.
+type Entry struct {
+	Tag string
+	// Date is the release date formatted as YYYY-MM-DD.
+	Date string
+	// Notes is the release notes Markdown; its headings are nested under the release heading.
+	Notes string
+	// CompareURL is the target of the link reference; no link is written when empty.
+	CompareURL string
+}
+
+// Options configure the section heading and link reference formats.
+// Empty values select the defaults; a Link of "none" disables link references.
+type Options struct {
+	Heading string
+	Link    string
+}
+
+// section is a release section: its heading line, key and body lines.
+type section struct {
+	key   string
+	lines []string
+}
+
+// Update inserts the entry into the changelog content, replacing an existing
+// section for the same release. Release sections are kept sorted by version,
+// newest first; sections that are not versions (e.g. "Unreleased") stay on top.
+func Update(content string, e Entry, opts Options) (string, error) {
+	if opts.Heading == "" {
+		opts.Heading = DefaultHeading
+	}
+
+	if opts.Link == "" {
+		opts.Link = DefaultLink
+	}
+
+	if strings.TrimSpace(content) == "" {
+		content = defaultHeader
+	}
+
+	level := headingOf(opts.Heading)
+	if level == 0 {
+		return "", fmt.Errorf("changelog heading %q must start with a markdown heading (e.g. \"## \")", opts.Heading)
+	}
+
+	version := strings.TrimPrefix(e.Tag, "v")
+
+	keyOf := sectionKeys(opts.Heading, level)
+	preamble, sections, links := split(content, level, keyOf)
+
+	heading := expand(opts.Heading, e, "")
+	entry := section{key: keyOf(heading), lines: render(heading, e.Notes, level)}
+
+	sections = insertSorted(sections, entry, e.Tag)
+
+	if opts.Link != "none" && e.CompareURL != "" {
+		def := expand(opts.Link, e, e.CompareURL)
+		if !linkDefRe.MatchString(def) {
+			return "", fmt.Errorf("changelog link %q must be a link reference definition (e.g. \"[{version}]: {url}\")",
+				opts.Link)
+		}
+
+		links = upsertLink(links, def, []string{version, e.Tag})
+	}
+
+	return join(preamble, sections, links), nil
+}
+
+// split separates the changelog into the text before the first release section,
+// the release sections, and the trailing block of link reference definitions.
+func split(content string, level int, keyOf func(heading string) string) ([]string, []section, []string) {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	// Trailing link definitions (and blank lines between them)
+	end := len(lines)
+	for end > 0 && (strings.TrimSpace(lines[end-1]) == "" || linkDefRe.MatchString(lines[end-1])) {
+		end--
+	}
+
+	var links []string
+
+	for _, l := range lines[end:] {
+		if linkDefRe.MatchString(l) {
+			links = append(links, l)
+		}
+	}
+
+	lines = lines[:end]
+
+	var preamble []string
+
+	var sections []section
+
+	inFence := false
+
+	for _, l := range lines {
+		if isFence(l) {
+			inFence = !inFence
+		}
+
+		if !inFence && headingOf(l) == level {
+			sections = append(sections, section{key: keyOf(l), lines: []string{l}})
+
+			continue
+		}
+
+		if len(sections) == 0 {
+			preamble = append(preamble, l)
+		} else {
+			last := &sections[len(sections)-1]
+			last.lines = append(last.lines, l)
+		}
+	}
+
+	return preamble, sections, links
+}
+
+// insertSorted replaces the section with the same key, or inserts the entry before
+// the first section with a lower version.
+func insertSorted(sections []section, entry section, tag string) []section {
+	for i, s := range sections {
+		if s.key == entry.key {
+			sections[i] = entry
+
+			return sections
+		}
+	}
+
+	v, ok := semver.Parse(tag)
+
+	for i, s := range sections {
+		other, isVersion := semver.Parse(s.key)
+		if !isVersion {
+			continue
+		}
+
+		if !ok || semver.Compare(v, other) > 0 {
+			return slices.Insert(sections, i, entry)
+		}
+	}
+
+	return append(sections, entry)
+}
+
+// upsertLink replaces the definition for any of the given labels or adds a new one,
+// then orders definitions like the sections: non-versions first, then newest first.
+func upsertLink(links []string, def string, labels []string) []string {
+	links = slices.DeleteFunc(links, func(l string) bool {
+		m := linkDefRe.FindStringSubmatch(l)
+
+		return m != nil && slices.Contains(labels, m[1])
+	})
+
+	links = append(links, def)
+
+	slices.SortStableFunc(links, func(a, b string) int {
+		va, okA := semver.Parse(linkDefRe.FindStringSubmatch(a)[1])
+		vb, okB := semver.Parse(linkDefRe.FindStringSubmatch(b)[1])
+
+		switch {
+		case !okA && !okB:
+			return 0
+		case !okA:
+			return -1
+		case !okB:
+			return 1
+		default:
+			return semver.Compare(vb, va)
+		}
+	})
+
+	return links
+}
+
+// render builds the section lines: heading, blank line, notes with nested headings.
+func render(heading, notes string, level int) []string {
+	lines := []string{heading, ""}
+	lines = append(lines, demoteHeadings(strings.Split(strings.TrimSpace(notes), "\n"), level)...)
+
+	return lines
+}
+
+// demoteHeadings shifts headings so that none is at or above the section level.
+func demoteHeadings(lines []string, level int) []string {
+	minLevel := 0
+	inFence := false
+
+	for _, l := range lines {
+		if isFence(l) {
+			inFence = !inFence
+		}
+
+		if h := headingOf(l); !inFence && h > 0 && (minLevel == 0 || h < minLevel) {
+			minLevel = h
+		}
+	}
+
+	shift := level + 1 - minLevel
+	if minLevel == 0 || shift <= 0 {
+		return lines
+	}
+
+	out := make([]string, len(lines))
+	inFence = false
+
+	for i, l := range lines {
+		if isFence(l) {
+			inFence = !inFence
+		}
+
+		if !inFence && headingOf(l) > 0 {
+			l = strings.Repeat("#", shift) + l
+		}
+
+		out[i] = l
+	}
+
+	return out
+}
+
+func join(preamble []string, sections []section, links []string) string {
+	var parts []string
+
+	if p := strings.TrimRight(strings.Join(preamble, "\n"), "\n"); p != "" {
+		parts = append(parts, p)
+	}
+
+	for _, s := range sections {
+		parts = append(parts, strings.TrimRight(strings.Join(s.lines, "\n"), "\n"))
+	}
+
+	if len(links) > 0 {
+		parts = append(parts, strings.Join(links, "\n"))
+	}
+
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// sectionKeys returns the function extracting the release identifier from a section
+// heading. Headings that follow the heading format give the value of its {version} or
+// {tag} placeholder, wherever it is, e.g. "1.2.0" in "## Release v1.2.0 (2025-01-01)"
+// for "## Release {tag} ({date})". Other headings, written with an earlier format or by
+// hand, give the first version they contain, or else their text, e.g. "Unreleased".
+func sectionKeys(format string, level int) func(heading string) string {
+	var pattern strings.Builder
+
+	pattern.WriteString("^")
+
+	captured, last := false, 0
+
+	for _, loc := range placeholderRe.FindAllStringIndex(format, -1) {
+		pattern.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+
+		switch name := format[loc[0]:loc[1]]; {
+		case (name == "{version}" || name == "{tag}") && !captured:
+			pattern.WriteString(`v?(\S+?)`)
+
+			captured = true
+		default:
+			pattern.WriteString(`.*?`)
+		}
+
+		last = loc[1]
+	}
+
+	pattern.WriteString(regexp.QuoteMeta(format[last:]) + `$`)
+
+	formatRe := regexp.MustCompile(pattern.String())
+
+	return func(heading string) string {
+		heading = strings.TrimSpace(heading)
+
+		if m := formatRe.FindStringSubmatch(heading); captured && m != nil {
+			return strings.TrimPrefix(m[1], "v")
+		}
+
+		if v := versionRe.FindString(heading); v != "" {
+			return strings.TrimPrefix(v, "v")
+		}
+
+		rest := strings.TrimSpace(heading[level:])
+		if strings.HasPrefix(rest, "[") {
+			if end := strings.Index(rest, "]"); end > 0 {
+				return rest[1:end]
+			}
+		}
+
+		return rest
+	}
+}
+
+func expand(format string, e Entry, url string) string {
+	return strings.NewReplacer(
+		"{tag}", e.Tag,
+		"{version}", strings.TrimPrefix(e.Tag, "v"),
+		"{date}", e.Date,
+		"{url}", url,
+	).Replace(format)
+}
+
+// headingOf returns the markdown heading level (1-6) of a line, or 0 if it is not a heading.
+func headingOf(line string) int {
+	i := 0
+	for i < len(line) && line[i] == '#' {
+		i++
+	}
+
+	if i > 0 && i <= 6 && i < len(line) && line[i] == ' ' {
+		return i
+	}
+
+	return 0
+}
+
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
@@ -0,0 +1,168 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdate_creates_changelog(t *testing.T) {
+	got, err := Update("", Entry{
+		Tag:        "v1.0.0",
+		Date:       "2025-01-01",
+		Notes:      "## Features\n\n- First release",
+		CompareURL: "https://example.com/compare/v0.9.0...v1.0.0",
+	}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := defaultHeader + "\n" +
+		"## [1.0.0] - 2025-01-01\n\n### Features\n\n- First release\n\n" +
+		"[1.0.0]: https://example.com/compare/v0.9.0...v1.0.0\n"
+
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUpdate_inserts_sorted_by_version(t *testing.T) {
+	content := "# Changelog\n\n## [Unreleased]\n\n- wip\n\n" +
+		"## [2.0.0] - 2025-03-01\n\n- two\n\n" +
+		"## [1.0.0] - 2025-01-01\n\n- one\n\n" +
+		"[Unreleased]: https://x/compare/v2.0.0...HEAD\n" +
+		"[2.0.0]: https://x/compare/v1.0.0...v2.0.0\n"
+
+	got, err := Update(content, Entry{
+		Tag:        "v1.4.3",
+		Date:       "2025-04-01",
+		Notes:      "- backport",
+		CompareURL: "https://x/compare/v1.4.2...v1.4.3",
+	}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	order := []string{"## [Unreleased]", "## [2.0.0]", "## [1.4.3] - 2025-04-01", "## [1.0.0]",
+		"[Unreleased]: ", "[2.0.0]: ", "[1.4.3]: https://x/compare/v1.4.2...v1.4.3"}
+
+	last := -1
+	for _, s := range order {
+		i := strings.Index(got, s)
+		if i <= last {
+			t.Fatalf("%q out of order in:\n%s", s, got)
+		}
+
+		last = i
+	}
+}
+
+func TestUpdate_replaces_existing_section(t *testing.T) {
+	entry := Entry{Tag: "v1.0.0", Date: "2025-01-01", Notes: "- new text", CompareURL: "https://x/c"}
+
+	content := "# Changelog\n\n## [1.0.0] - 2024-12-31\n\n- old text\n\n[1.0.0]: https://x/old\n"
+
+	got, err := Update(content, entry, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(got, "old text") || strings.Contains(got, "https://x/old") {
+		t.Errorf("old section or link kept:\n%s", got)
+	}
+
+	again, err := Update(got, entry, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if again != got {
+		t.Errorf("update is not idempotent:\n%s\nvs\n%s", got, again)
+	}
+}
+
+func TestUpdate_custom_formats(t *testing.T) {
+	got, err := Update("# History\n", Entry{Tag: "v2.1.0", Date: "2025-05-05", Notes: "- x", CompareURL: "u"},
+		Options{Heading: "### {tag} ({date})", Link: "none"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "# History\n\n### v2.1.0 (2025-05-05)\n\n- x\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestUpdate_invalid_heading(t *testing.T) {
+	if _, err := Update("", Entry{Tag: "v1.0.0"}, Options{Heading: "{version}"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestUpdate_invalid_link(t *testing.T) {
+	if _, err := Update("", Entry{Tag: "v1.0.0", CompareURL: "u"}, Options{Link: "{url}"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestDemoteHeadings_skips_code_fences(t *testing.T) {
+	lines := []string{"## Features", "```", "# not a heading", "```", "### Details"}
+
+	got := demoteHeadings(lines, 2)
+	want := []string{"### Features", "```", "# not a heading", "```", "#### Details"}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSectionKeys(t *testing.T) {
+	tests := []struct {
+		format  string
+		heading string
+		want    string
+	}{
+		{DefaultHeading, "## [1.2.0] - 2025-01-01", "1.2.0"},
+		{DefaultHeading, "## [v1.2.0]", "1.2.0"},
+		{DefaultHeading, "## v1.2.0 (2025-01-01)", "1.2.0"},
+		{DefaultHeading, "## [Unreleased]", "Unreleased"},
+		{"## Release {tag} ({date})", "## Release v1.2.0 (2025-01-01)", "1.2.0"},
+		{"## {date}: version {version}", "## 2025-01-01: version 2.0.0-rc.1", "2.0.0-rc.1"},
+		{"## Release {tag} ({date})", "## Release notes archive", "Release notes archive"},
+	}
+
+	for _, tt := range tests {
+		if got := sectionKeys(tt.format, 2)(tt.heading); got != tt.want {
+			t.Errorf("sectionKeys(%q)(%q) = %q, want %q", tt.format, tt.heading, got, tt.want)
+		}
+	}
+}
+
+func TestUpdate_heading_with_version_not_first(t *testing.T) {
+	opts := Options{Heading: "## Release {tag} ({date})", Link: "none"}
+
+	got, err := Update("# Changelog\n", Entry{Tag: "v1.2.0", Date: "2025-01-01", Notes: "- one two"}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, e := range []Entry{
+		{Tag: "v1.3.0", Date: "2025-02-01", Notes: "- one three"},
+		{Tag: "v1.2.1", Date: "2025-01-15", Notes: "- one two one"},
+		{Tag: "v1.3.0", Date: "2025-02-02", Notes: "- one three, again"},
+	} {
+		if got, err = Update(got, e, opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := "# Changelog\n\n" +
+		"## Release v1.3.0 (2025-02-02)\n\n- one three, again\n\n" +
+		"## Release v1.2.1 (2025-01-15)\n\n- one two one\n\n" +
+		"## Release v1.2.0 (2025-01-01)\n\n- one two\n"
+
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
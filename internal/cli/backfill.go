@@ -0,0 +1,596 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AndreyAkinshin/herald/internal/config"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/forge"
+	"github.com/AndreyAkinshin/herald/internal/history"
+	"github.com/AndreyAkinshin/herald/internal/term"
+)
+
+const defaultBackfillJobs = 4
+
+// Backfill statuses, as shown in the summary table and stored in the checkpoint.
+const (
+	statusPending   = "pending"
+	statusGenerated = "generated"
+	statusFailed    = "failed"
+	statusSkipped   = "skipped"
+	statusUpdated   = "updated"
+)
+
+// backfillItem tracks one release through a backfill run.
+type backfillItem struct {
+	Release forge.Release
+	Draft   *draft
+	Status  string
+	Err     error
+}
+
+// checkpoint records backfill progress so an interrupted run can be resumed.
+type checkpoint struct {
+	Repo string `json:"repo"`
+	// Settings is the fingerprint of the settings the notes were generated with.
+	Settings string                      `json:"settings"`
+	Releases map[string]*checkpointEntry `json:"releases"`
+
+	// discarded counts the releases left out when loading because they were generated
+	// with other settings.
+	discarded int
+}
+
+// notesKeys are the settings that change the generated notes. A checkpoint written with
+// other values is not resumed.
+var notesKeys = []string{
+	config.KeyModel, config.KeyBackend, config.KeyPrevious, config.KeyPrereleases,
+	config.KeyInstructions, config.KeySections, config.KeyExclude, config.KeyExcludeAuthors,
+	config.KeyExcludePaths, config.KeyNoMerges, config.KeyFirstParent, config.KeyFooter, config.KeyNoFooter,
+	config.KeyTokenBudget, config.KeyDiffs, config.KeyComponents,
+}
+
+type checkpointEntry struct {
+	PrevTag string `json:"prevTag"`
+	Output  string `json:"output"`
+	Status  string `json:"status"`
+}
+
+func parseBackfillArgs(cfg *Config, args []string) (*Config, error) {
+	fs := newFlagSet(cfg, "herald backfill", printBackfillUsage)
+	fs.StringVar(&cfg.From, "from", "", "")
+	fs.StringVar(&cfg.To, "to", "", "")
+	fs.BoolVar(&cfg.All, "all", false, "")
+	fs.IntVar(&cfg.Jobs, "jobs", defaultBackfillJobs, "")
+	fs.IntVar(&cfg.Jobs, "j", defaultBackfillJobs, "")
+	fs.StringVar(&cfg.Checkpoint, "checkpoint", "", "")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return nil, errors.Config(err.Error())
+	}
+
+	switch {
+	case cfg.All && (cfg.From != "" || cfg.To != ""):
+		return nil, errors.Config("--all cannot be combined with --from or --to")
+	case !cfg.All && cfg.From == "" && cfg.To == "":
+		return nil, errors.Config("specify a range with --from/--to, or --all")
+	case cfg.Jobs < 1:
+		return nil, errors.Config("--jobs must be at least 1")
+	case fs.NArg() > 1:
+		return nil, errors.Config("unexpected argument: " + fs.Arg(1))
+	}
+
+	cfg.Instructions = fs.Arg(0)
+	cfg.flagSettings = flagSettings(fs, cfg)
+
+	return cfg, nil
+}
+
+func printBackfillUsage() {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s — Regenerate notes for a range of releases\n", term.BoldCyan("herald backfill"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("USAGE"))
+	fmt.Fprintf(&b, "    %s %s %s %s\n",
+		term.BoldCyan("herald backfill"),
+		term.Yellow("(--from <tag> --to <tag> | --all)"),
+		term.Yellow("[\"instructions\"]"),
+		term.Dim("[options]"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("OPTIONS"))
+	fmt.Fprintf(&b, "        %s %s          First release to regenerate %s\n",
+		term.Green("--from"), term.Yellow("<tag>"), term.Dim("(default: oldest)"))
+	fmt.Fprintf(&b, "        %s %s            Last release to regenerate %s\n",
+		term.Green("--to"), term.Yellow("<tag>"), term.Dim("(default: latest)"))
+	fmt.Fprintf(&b, "        %s                 Regenerate every release\n", term.Green("--all"))
+	fmt.Fprintf(&b, "        %s %s  Only releases of a monorepo component\n",
+		term.Green("--component"), term.Yellow("<name>"))
+	fmt.Fprintf(&b, "    %s %s %s        Parallel generations %s\n",
+		term.Green("-j,"), term.Green("--jobs"), term.Yellow("<n>"), term.Dim("(default: 4)"))
+	fmt.Fprintf(&b, "        %s %s  Progress file for resuming\n",
+		term.Green("--checkpoint"), term.Yellow("<file>"))
+	fmt.Fprintf(&b, "                            %s\n",
+		term.Dim("(default: <tmpdir>/herald/<repo>-backfill.json)"))
+	fmt.Fprintf(&b, "        %s        Update all releases without asking\n", term.Green("--no-confirm"))
+	fmt.Fprintf(&b, "        %s           Generate notes but don't update releases\n", term.Green("--dry-run"))
+	fmt.Fprintf(&b, "        %s             Overwrite release notes not generated by herald\n", term.Green("--force"))
+	b.WriteString("\n")
+	b.WriteString("    Model, backend, forge and footer options are the same as for a single release.\n")
+	b.WriteString("\n")
+
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// runBackfill regenerates notes for every release in the selected range, then
+// updates them after a summary and per-release confirmation.
+func runBackfill(ctx context.Context, cfg *Config) error {
+	if !strings.Contains(cfg.Output, "{tag}") {
+		return errors.Config("the output setting must contain {tag} to backfill several releases")
+	}
+
+	s, err := newSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	targets, err := selectBackfillReleases(s.releases, cfg.From, cfg.To, cfg.All)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Checkpoint == "" {
+		cfg.Checkpoint = filepath.Join(tempDir, s.repoInfo.Name+"-backfill.json")
+	}
+
+	cp, err := loadCheckpoint(cfg.Checkpoint, s.repoInfo.NameWithOwner, settingsFingerprint(cfg))
+	if err != nil {
+		return err
+	}
+
+	if cp.discarded > 0 {
+		fmt.Fprintln(stdout, term.Yellow(fmt.Sprintf("Checkpoint %s was written with other settings; "+
+			"generating its %d releases again", cfg.Checkpoint, cp.discarded)))
+	}
+
+	if len(cp.Releases) > 0 {
+		fmt.Fprintf(stdout, "Resuming from checkpoint %s\n", term.Cyan(cfg.Checkpoint))
+	}
+
+	items, pending := s.prepareBackfill(ctx, targets, cp)
+
+	if len(pending) > 0 {
+		fmt.Fprintf(stdout, "Generating notes for %d releases with %s (%d parallel)...\n",
+			len(pending), s.generatorName(), cfg.Jobs)
+
+		s.generateBackfill(ctx, pending, cp)
+	}
+
+	if err := s.updateBackfillChangelog(items); err != nil {
+		return err
+	}
+
+	printBackfillSummary(items)
+
+	if cfg.DryRun {
+		fmt.Fprintln(stdout, term.Yellow("\nDry run: releases not updated"))
+
+		return nil
+	}
+
+	if s.localOnly() {
+		return finishBackfill(items, cfg.Checkpoint)
+	}
+
+	if err := s.updateBackfill(ctx, items, cp); err != nil {
+		return err
+	}
+
+	return finishBackfill(items, cfg.Checkpoint)
+}
+
+// selectBackfillReleases returns the published releases between from and to (inclusive),
+// oldest first. Empty bounds extend to the oldest and latest releases.
+func selectBackfillReleases(releases []forge.Release, from, to string, all bool) ([]forge.Release, error) {
+	var published []forge.Release
+
+	for _, r := range releases {
+		if !r.IsDraft {
+			published = append(published, r)
+		}
+	}
+
+	sort.SliceStable(published, func(i, j int) bool {
+		return published[i].PublishedAt.Before(published[j].PublishedAt)
+	})
+
+	if len(published) == 0 {
+		return nil, errors.Runtime("no releases found", nil)
+	}
+
+	if all {
+		return published, nil
+	}
+
+	start, end := 0, len(published)-1
+
+	indexOf := func(tag string) (int, error) {
+		i := slices.IndexFunc(published, func(r forge.Release) bool { return r.TagName == tag })
+		if i == -1 {
+			return 0, errors.Runtime("release "+tag+" not found", nil)
+		}
+
+		return i, nil
+	}
+
+	var err error
+
+	if from != "" {
+		if start, err = indexOf(from); err != nil {
+			return nil, err
+		}
+	}
+
+	if to != "" {
+		if end, err = indexOf(to); err != nil {
+			return nil, err
+		}
+	}
+
+	if start > end {
+		return nil, errors.Config("--from " + from + " was published after --to " + to)
+	}
+
+	return published[start : end+1], nil
+}
+
+// prepareBackfill builds prompts for releases that still need notes and restores
+// progress recorded in the checkpoint. It returns all items and those pending generation.
+func (s *session) prepareBackfill(
+	ctx context.Context, targets []forge.Release, cp *checkpoint,
+) ([]*backfillItem, []*backfillItem) {
+	items := make([]*backfillItem, 0, len(targets))
+
+	var pending []*backfillItem
+
+	for _, r := range targets {
+		item := &backfillItem{Release: r, Status: statusPending}
+		items = append(items, item)
+
+		if entry, ok := cp.Releases[r.TagName]; ok {
+			if restoreFromCheckpoint(item, entry) {
+				item.Draft.Component, _ = componentOf(s.cfg, r.TagName)
+
+				continue
+			}
+		}
+
+		d, err := s.prepare(ctx, r.TagName, s.outputPath(r.TagName))
+		if err != nil {
+			item.Status, item.Err = statusFailed, err
+
+			continue
+		}
+
+		item.Draft = d
+		pending = append(pending, item)
+	}
+
+	return items, pending
+}
+
+// restoreFromCheckpoint reuses a previous run's result if its notes file still exists.
+func restoreFromCheckpoint(item *backfillItem, entry *checkpointEntry) bool {
+	if entry.Status != statusGenerated && entry.Status != statusUpdated {
+		return false
+	}
+
+	notes, err := os.ReadFile(entry.Output)
+	if err != nil {
+		return false
+	}
+
+	item.Draft = &draft{
+		Tag:     item.Release.TagName,
+		PrevTag: entry.PrevTag,
+		Output:  entry.Output,
+		Notes:   string(notes),
+	}
+	item.Status = entry.Status
+
+	return true
+}
+
+// generateBackfill generates notes for pending items with at most cfg.Jobs in flight,
+// recording each result in the checkpoint as soon as it is available.
+func (s *session) generateBackfill(ctx context.Context, pending []*backfillItem, cp *checkpoint) {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+
+	sem := make(chan struct{}, s.cfg.Jobs)
+
+	for _, item := range pending {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			err := s.generate(ctx, item.Draft)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			done++
+
+			if err != nil {
+				item.Status, item.Err = statusFailed, err
+				fmt.Fprintf(stdout, "[%d/%d] %s %s\n", done, len(pending), term.Yellow(item.Release.TagName), term.Dim(err.Error()))
+
+				return
+			}
+
+			item.Status = statusGenerated
+			cp.record(item)
+
+			if err := cp.save(s.cfg.Checkpoint); err != nil {
+				fmt.Fprintf(stdout, "%s %v\n", term.Yellow("Warning:"), err)
+			}
+
+			fmt.Fprintf(stdout, "[%d/%d] %s\n", done, len(pending), term.Green(item.Release.TagName))
+		}()
+	}
+
+	wg.Wait()
+}
+
+// updateBackfillChangelog inserts every generated release into its changelog, oldest first.
+func (s *session) updateBackfillChangelog(items []*backfillItem) error {
+	counts := map[string]int{}
+
+	for _, item := range items {
+		if item.Status != statusGenerated && item.Status != statusUpdated {
+			continue
+		}
+
+		path := s.changelogPath(item.Draft)
+		if path == "" {
+			continue
+		}
+
+		if err := s.updateChangelog(item.Draft); err != nil {
+			return err
+		}
+
+		counts[path]++
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Fprintf(stdout, "Changelog updated with %d releases: %s\n", counts[path], term.Cyan(path))
+	}
+
+	return nil
+}
+
+// updateBackfill publishes generated notes, asking for each release unless --no-confirm is set.
+func (s *session) updateBackfill(ctx context.Context, items []*backfillItem, cp *checkpoint) error {
+	confirmAll := s.cfg.NoConfirm
+
+	for _, item := range items {
+		if item.Status != statusGenerated {
+			continue
+		}
+
+		tag := item.Release.TagName
+
+		current, err := s.checkOverwrite(ctx, tag)
+		if err != nil {
+			item.Status = statusSkipped
+			fmt.Fprintf(stdout, "%s %s: %v\n", term.Yellow("Skipping"), tag, err)
+
+			continue
+		}
+
+		if !confirmAll {
+			fmt.Fprintln(stdout, term.Dim("\n--- "+tag+" ---"))
+			fmt.Fprintln(stdout, item.Draft.Notes)
+			fmt.Fprintln(stdout, term.Dim("--- End "+tag+" ---"))
+
+			if strings.TrimSpace(current) != "" {
+				printDiff(item.Draft, current)
+			}
+
+			switch ask(ctx, "Update release "+tag+"?", "[y]es/[n]o/[a]ll/[q]uit:") {
+			case "y", "yes":
+			case "a", "all":
+				confirmAll = true
+			case "q", "quit":
+				return errors.UserAbort()
+			default:
+				item.Status = statusSkipped
+
+				continue
+			}
+		}
+
+		if err := s.publish(ctx, tag, item.Draft.Output, modelLabel(s.cfg), current, history.ActionUpdate); err != nil {
+			item.Status, item.Err = statusFailed, err
+			fmt.Fprintf(stdout, "%s %s: %v\n", term.Yellow("Failed to update"), tag, err)
+
+			continue
+		}
+
+		item.Status = statusUpdated
+		cp.record(item)
+
+		if err := cp.save(s.cfg.Checkpoint); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(stdout, term.Green("Release "+tag+" updated successfully"))
+	}
+
+	return nil
+}
+
+// finishBackfill removes the checkpoint once every release is updated, or reports what is left.
+func finishBackfill(items []*backfillItem, checkpointPath string) error {
+	var failed, skipped int
+
+	for _, item := range items {
+		switch item.Status {
+		case statusFailed:
+			failed++
+		case statusSkipped:
+			skipped++
+		}
+	}
+
+	if failed == 0 && skipped == 0 {
+		_ = os.Remove(checkpointPath)
+
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "\n%d failed, %d skipped; rerun the same command to resume from %s\n",
+		failed, skipped, term.Cyan(checkpointPath))
+
+	if failed > 0 {
+		return errors.Runtime(fmt.Sprintf("%d releases failed", failed), nil)
+	}
+
+	return nil
+}
+
+func printBackfillSummary(items []*backfillItem) {
+	tagWidth, prevWidth := len("TAG"), len("PREVIOUS")
+
+	for _, item := range items {
+		tagWidth = max(tagWidth, len(item.Release.TagName))
+
+		if item.Draft != nil {
+			prevWidth = max(prevWidth, len(item.Draft.PrevTag))
+		}
+	}
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, term.Bold(fmt.Sprintf("%-*s  %-*s  %-9s  %s",
+		tagWidth, "TAG", prevWidth, "PREVIOUS", "STATUS", "NOTES")))
+
+	for _, item := range items {
+		prev, notes := "", ""
+
+		if item.Draft != nil {
+			prev = item.Draft.PrevTag
+			notes = item.Draft.Output
+		}
+
+		if prev == "" && item.Draft != nil {
+			prev = "(root)"
+		}
+
+		if item.Err != nil {
+			notes = item.Err.Error()
+		}
+
+		status := fmt.Sprintf("%-9s", item.Status)
+
+		switch item.Status {
+		case statusFailed:
+			status = term.Yellow(status)
+		case statusUpdated:
+			status = term.Dim(status)
+		default:
+			status = term.Green(status)
+		}
+
+		fmt.Fprintf(stdout, "%-*s  %-*s  %s  %s\n", tagWidth, item.Release.TagName, prevWidth, prev, status, term.Dim(notes))
+	}
+}
+
+// loadCheckpoint reads the checkpoint file, returning an empty checkpoint if it does not exist
+// or was written with settings of another fingerprint.
+func loadCheckpoint(path, repo, settings string) (*checkpoint, error) {
+	cp := &checkpoint{Repo: repo, Settings: settings, Releases: map[string]*checkpointEntry{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+
+	if err != nil {
+		return nil, errors.Runtime("failed to read checkpoint", err)
+	}
+
+	var stored checkpoint
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, errors.Runtime("failed to parse checkpoint "+path, err)
+	}
+
+	if stored.Repo != repo {
+		return nil, errors.Config("checkpoint " + path + " belongs to " + stored.Repo + ", not " + repo)
+	}
+
+	if stored.Settings != settings {
+		cp.discarded = len(stored.Releases)
+	} else if stored.Releases != nil {
+		cp.Releases = stored.Releases
+	}
+
+	return cp, nil
+}
+
+// settingsFingerprint hashes the settings that change the generated notes.
+func settingsFingerprint(cfg *Config) string {
+	values := make(map[string]any, len(notesKeys))
+	for _, key := range notesKeys {
+		values[key] = cfg.Get(key)
+	}
+
+	data, _ := json.Marshal(values)
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func (cp *checkpoint) record(item *backfillItem) {
+	cp.Releases[item.Release.TagName] = &checkpointEntry{
+		PrevTag: item.Draft.PrevTag,
+		Output:  item.Draft.Output,
+		Status:  item.Status,
+	}
+}
+
+func (cp *checkpoint) save(path string) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return errors.Runtime("failed to encode checkpoint", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Runtime("failed to create checkpoint directory", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return errors.Runtime("failed to write checkpoint", err)
+	}
+
+	return nil
+}
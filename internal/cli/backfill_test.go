@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/forge"
+)
+
+func backfillReleases() []forge.Release {
+	return []forge.Release{
+		{TagName: "v3.0", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v1.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v4.0-draft", IsDraft: true},
+		{TagName: "v2.0", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func tagNames(releases []forge.Release) []string {
+	names := make([]string, 0, len(releases))
+	for _, r := range releases {
+		names = append(names, r.TagName)
+	}
+
+	return names
+}
+
+func TestParseArgs_backfill(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"backfill", "--from", "v1.0", "--to", "v2.0", "-j", "2", "Be brief"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Command != commandBackfill || cfg.From != "v1.0" || cfg.To != "v2.0" || cfg.Jobs != 2 {
+		t.Errorf("got %+v", cfg)
+	}
+
+	if cfg.Instructions != "Be brief" {
+		t.Errorf("Instructions = %q, want %q", cfg.Instructions, "Be brief")
+	}
+}
+
+func TestParseArgs_backfill_requires_range(t *testing.T) {
+	if _, err := ParseArgs("1.0.0", []string{"backfill"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseArgs_backfill_all_conflicts_with_range(t *testing.T) {
+	if _, err := ParseArgs("1.0.0", []string{"backfill", "--all", "--from", "v1.0"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseArgs_backfill_invalid_jobs(t *testing.T) {
+	if _, err := ParseArgs("1.0.0", []string{"backfill", "--all", "--jobs", "0"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestSelectBackfillReleases_all(t *testing.T) {
+	got, err := selectBackfillReleases(backfillReleases(), "", "", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if names := tagNames(got); len(names) != 3 || names[0] != "v1.0" || names[2] != "v3.0" {
+		t.Errorf("got %v, want [v1.0 v2.0 v3.0]", names)
+	}
+}
+
+func TestSelectBackfillReleases_range(t *testing.T) {
+	got, err := selectBackfillReleases(backfillReleases(), "v2.0", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if names := tagNames(got); len(names) != 2 || names[0] != "v2.0" || names[1] != "v3.0" {
+		t.Errorf("got %v, want [v2.0 v3.0]", names)
+	}
+}
+
+func TestSelectBackfillReleases_reversed_range(t *testing.T) {
+	if _, err := selectBackfillReleases(backfillReleases(), "v3.0", "v1.0", false); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestSelectBackfillReleases_unknown_tag(t *testing.T) {
+	if _, err := selectBackfillReleases(backfillReleases(), "v9.0", "", false); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCheckpoint_roundtrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backfill.json")
+	output := filepath.Join(dir, "repo-v2.0.md")
+
+	if err := os.WriteFile(output, []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := loadCheckpoint(path, "owner/repo", "settings")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	item := &backfillItem{
+		Release: forge.Release{TagName: "v2.0"},
+		Draft:   &draft{Tag: "v2.0", PrevTag: "v1.0", Output: output},
+		Status:  statusGenerated,
+	}
+	cp.record(item)
+
+	if err := cp.save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := loadCheckpoint(path, "owner/repo", "settings")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	restored := &backfillItem{Release: forge.Release{TagName: "v2.0"}}
+	if !restoreFromCheckpoint(restored, loaded.Releases["v2.0"]) {
+		t.Fatal("expected checkpoint entry to be restored")
+	}
+
+	if restored.Status != statusGenerated || restored.Draft.PrevTag != "v1.0" || restored.Draft.Notes != "notes" {
+		t.Errorf("got %+v / %+v", restored, restored.Draft)
+	}
+}
+
+func TestCheckpoint_other_repo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backfill.json")
+
+	cp, _ := loadCheckpoint(path, "owner/one", "settings")
+	if err := cp.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadCheckpoint(path, "owner/two", "settings"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCheckpoint_other_settings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backfill.json")
+
+	cp, _ := loadCheckpoint(path, "owner/repo", settingsFingerprint(&Config{}))
+	cp.record(&backfillItem{
+		Release: forge.Release{TagName: "v1.0"},
+		Draft:   &draft{Tag: "v1.0", Output: "notes.md"},
+		Status:  statusGenerated,
+	})
+
+	if err := cp.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	other := &Config{}
+	other.Instructions = "Focus on the API"
+
+	loaded, err := loadCheckpoint(path, "owner/repo", settingsFingerprint(other))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(loaded.Releases) != 0 || loaded.discarded != 1 {
+		t.Errorf("got %d releases and %d discarded, want 0 and 1", len(loaded.Releases), loaded.discarded)
+	}
+}
+
+func TestRestoreFromCheckpoint_missing_notes_file(t *testing.T) {
+	item := &backfillItem{Release: forge.Release{TagName: "v1.0"}}
+	entry := &checkpointEntry{Output: filepath.Join(t.TempDir(), "gone.md"), Status: statusGenerated}
+
+	if restoreFromCheckpoint(item, entry) {
+		t.Error("expected restore to fail when the notes file is gone")
+	}
+}
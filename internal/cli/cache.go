@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/cache"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/prompt"
+	"github.com/AndreyAkinshin/herald/internal/term"
+)
+
+// Actions of herald cache.
+const (
+	cacheStats = "stats"
+	cachePrune = "prune"
+	cacheClear = "clear"
+)
+
+// defaultCacheAge is how long herald cache prune keeps unused summaries.
+const defaultCacheAge = 90 * 24 * time.Hour
+
+func parseCacheArgs(cfg *Config, args []string) (*Config, error) {
+	fs := newFlagSet(cfg, "herald cache", printCacheUsage)
+	fs.Func("older-than", "", func(v string) error {
+		d, err := parseAge(v)
+		cfg.OlderThan = d
+
+		return err
+	})
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return nil, errors.Config(err.Error())
+	}
+
+	actions := []string{cacheStats, cachePrune, cacheClear}
+
+	switch {
+	case fs.NArg() == 0:
+		return nil, errors.Config("missing cache action (expected: " + strings.Join(actions, ", ") + ")")
+	case !slices.Contains(actions, fs.Arg(0)):
+		return nil, errors.Config("unknown cache action: " + fs.Arg(0))
+	case fs.NArg() > 1:
+		return nil, errors.Config("unexpected argument: " + fs.Arg(1))
+	}
+
+	cfg.CacheAction = fs.Arg(0)
+	if cfg.OlderThan == 0 {
+		cfg.OlderThan = defaultCacheAge
+	}
+
+	cfg.flagSettings = flagSettings(fs, cfg)
+
+	return cfg, nil
+}
+
+// parseAge parses a duration that may also be given in days, e.g. "30d" or "12h".
+func parseAge(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return d, nil
+	}
+
+	return 0, fmt.Errorf("expected an age such as 30d or 12h")
+}
+
+func printCacheUsage() {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s — Commit summary cache\n", term.BoldCyan("herald cache"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("USAGE"))
+	fmt.Fprintf(&b, "    %s %s\n", term.BoldCyan("herald cache stats"), term.Dim("[options]"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald cache prune"), term.Yellow("[--older-than <age>]"), term.Dim("[options]"))
+	fmt.Fprintf(&b, "    %s %s\n", term.BoldCyan("herald cache clear"), term.Dim("[options]"))
+	b.WriteString("\n")
+	b.WriteString("    Notes are written from one-line summaries of the commits, which are cached per\n")
+	b.WriteString("    commit, model that wrote them, prompt version and diff mode, and reused by\n")
+	b.WriteString("    later runs and releases: regenerating notes only summarizes new commits.\n")
+	b.WriteString("    stats describes the cache; prune removes the summaries not used recently,\n")
+	b.WriteString("    written for an older prompt or left by interrupted runs; clear removes all.\n")
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("OPTIONS"))
+	fmt.Fprintf(&b, "        %s %s  Prune summaries not used for this long %s\n",
+		term.Green("--older-than"), term.Yellow("<age>"), term.Dim("(default: 90d)"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "    The cache is stored in %s.\n", term.Dim("$XDG_CACHE_HOME/herald/summaries"))
+	b.WriteString("\n")
+
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// runCache shows, prunes or clears the commit summary cache.
+func runCache(cfg *Config) error {
+	store, err := cache.Open()
+	if err != nil {
+		return err
+	}
+
+	switch cfg.CacheAction {
+	case cachePrune:
+		removed, err := store.Prune(cfg.OlderThan, prompt.SummaryVersion)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Removed %d cached summaries\n", removed)
+	case cacheClear:
+		if err := store.Clear(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Cleared %s\n", store.Dir())
+	default:
+		st, err := store.Stats(prompt.SummaryVersion)
+		if err != nil {
+			return err
+		}
+
+		printCacheStats(store.Dir(), st)
+	}
+
+	return nil
+}
+
+func printCacheStats(dir string, st cache.Stats) {
+	fmt.Fprintf(stdout, "%-10s  %s\n", "Location", dir)
+	fmt.Fprintf(stdout, "%-10s  %d %s\n", "Summaries", st.Entries,
+		term.Dim(fmt.Sprintf("(%d for an older prompt, removed by prune)", st.Stale)))
+	fmt.Fprintf(stdout, "%-10s  %.1f KiB\n", "Size", float64(st.Bytes)/1024)
+
+	if st.Entries == 0 {
+		return
+	}
+
+	fmt.Fprintf(stdout, "%-10s  %s to %s\n", "Last used",
+		st.Oldest.Local().Format("2006-01-02"), st.Newest.Local().Format("2006-01-02"))
+
+	for _, model := range slices.Sorted(maps.Keys(st.Models)) {
+		fmt.Fprintf(stdout, "%-10s  %s: %d\n", "Model", model, st.Models[model])
+	}
+}
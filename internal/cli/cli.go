@@ -3,56 +3,173 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/AndreyAkinshin/herald/internal/claude"
+	"github.com/AndreyAkinshin/herald/internal/changelog"
+	"github.com/AndreyAkinshin/herald/internal/config"
 	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/forge"
 	"github.com/AndreyAkinshin/herald/internal/git"
-	"github.com/AndreyAkinshin/herald/internal/github"
-	"github.com/AndreyAkinshin/herald/internal/prompt"
+	"github.com/AndreyAkinshin/herald/internal/history"
+	"github.com/AndreyAkinshin/herald/internal/llm"
 	"github.com/AndreyAkinshin/herald/internal/term"
 )
 
 var tempDir = filepath.Join(os.TempDir(), "herald")
 
+// stdin is shared by all prompts so buffered input is not lost between questions.
+var stdin = bufio.NewReader(os.Stdin)
+
+// stdout receives all human-readable output. Its writes are serialized, so the messages of
+// generations that run concurrently, such as the workers of a backfill, are written whole.
+var stdout = &output{w: os.Stdout}
+
+// output is a writer whose destination can be replaced while other goroutines write to it.
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.w.Write(p)
+}
+
+// redirect sends the output to w and returns a function that restores the previous destination.
+func (o *output) redirect(w io.Writer) (restore func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	prev := o.w
+	o.w = w
+
+	return func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		o.w = prev
+	}
+}
+
+// valueFlags lists flags that consume the following argument as their value.
+var valueFlags = []string{
+	"-o", "--output", "-m", "--model", "--backend", "--forge",
+	"--tag-pattern", "--previous", "--prereleases", "--component",
+	"--changelog", "--changelog-heading", "--changelog-link", "--format",
+	"--from", "--to", "-j", "--jobs", "--checkpoint", "--ref", "--since", "--until", "--timeout",
+	"--token-budget", "--older-than", "--diffs",
+}
+
+// Subcommands; the default command generates notes for a single release.
+const (
+	commandBackfill   = "backfill"
+	commandCache      = "cache"
+	commandConfig     = "config"
+	commandHistory    = "history"
+	commandRollback   = "rollback"
+	commandUnreleased = "unreleased"
+)
+
 // Config holds CLI configuration.
 type Config struct {
-	Tag          string
-	Instructions string
-	Output       string
-	Model        string
-	Version      string
-	NoConfirm    bool
-	NoFooter     bool
-	DryRun       bool
-	Verbose      bool
+	// Settings can also come from config files and the environment; see loadSettings.
+	config.Settings
+
+	Command   string
+	Tag       string
+	Version   string
+	NoConfirm bool
+	DryRun    bool
+	Force     bool
+	Verbose   bool
+	Format    string
+	// Component selects a monorepo component; by default it is detected from the tag prefix.
+	Component string
+
+	// flagSettings holds the settings given on the command line.
+	flagSettings config.Layer
+
+	// timeouts limit each phase of the run; parsed from the timeout setting.
+	timeouts map[string]time.Duration
+
+	// Ref is the branch or commit previewed by herald unreleased
+	Ref string
+
+	// Since and Until override the start and end of the commit range
+	Since string
+	Until string
+
+	// RollbackTo selects the archived version to restore (1 is the most recent)
+	RollbackTo int
+
+	// Backfill options
+	From       string
+	To         string
+	All        bool
+	Jobs       int
+	Checkpoint string
+
+	// Cache options: the action (stats, prune or clear) and the age of entries to prune
+	CacheAction string
+	OlderThan   time.Duration
 }
 
 // ParseArgs parses command-line arguments.
 // Returns (nil, nil) when --version is requested (caller should print version and exit).
 func ParseArgs(version string, args []string) (*Config, error) {
-	cfg := &Config{}
+	cfg := &Config{Version: version}
+
+	if len(args) > 0 && args[0] == commandBackfill {
+		cfg.Command = commandBackfill
+
+		return parseBackfillArgs(cfg, args[1:])
+	}
+
+	if len(args) > 0 && args[0] == commandCache {
+		cfg.Command = commandCache
+
+		return parseCacheArgs(cfg, args[1:])
+	}
+
+	if len(args) > 0 && args[0] == commandConfig {
+		cfg.Command = commandConfig
+
+		return parseConfigArgs(cfg, args[1:])
+	}
+
+	// "herald HEAD" is a shorthand for "herald unreleased"
+	if len(args) > 0 && (args[0] == commandUnreleased || args[0] == "HEAD") {
+		cfg.Command = commandUnreleased
+
+		return parseUnreleasedArgs(cfg, args[1:])
+	}
+
+	if len(args) > 0 && (args[0] == commandHistory || args[0] == commandRollback) {
+		cfg.Command = args[0]
+
+		return parseHistoryArgs(cfg, args[1:])
+	}
 
 	var showVersion bool
 
-	fs := flag.NewFlagSet("herald", flag.ContinueOnError)
-	fs.SetOutput(os.Stderr)
+	fs := newFlagSet(cfg, "herald", printUsage)
 	fs.StringVar(&cfg.Output, "output", "", "")
 	fs.StringVar(&cfg.Output, "o", "", "")
-	fs.StringVar(&cfg.Model, "model", "", "")
-	fs.StringVar(&cfg.Model, "m", "", "")
-	fs.BoolVar(&cfg.NoConfirm, "no-confirm", false, "")
-	fs.BoolVar(&cfg.NoFooter, "no-footer", false, "")
-	fs.BoolVar(&cfg.DryRun, "dry-run", false, "")
-	fs.BoolVar(&cfg.Verbose, "verbose", false, "")
-	fs.BoolVar(&cfg.Verbose, "v", false, "")
 	fs.BoolVar(&showVersion, "version", false, "")
-
-	fs.Usage = printUsage
+	fs.StringVar(&cfg.Format, "format", formatText, "")
+	fs.StringVar(&cfg.Since, "since", "", "")
+	fs.StringVar(&cfg.Until, "until", "", "")
 
 	// Reorder args to put flags before positional args (allows flags anywhere)
 	reordered := reorderArgs(args)
@@ -69,21 +186,69 @@ func ParseArgs(version string, args []string) (*Config, error) {
 		return nil, errors.Config("missing required argument: tag")
 	}
 
+	switch cfg.Format {
+	case formatText:
+	case formatJSON:
+		if !cfg.NoConfirm && !cfg.DryRun {
+			return nil, errors.Config("--format json cannot ask for confirmation; add --no-confirm or --dry-run")
+		}
+	default:
+		return nil, errors.Config("unknown format: " + cfg.Format + " (expected text or json)")
+	}
+
 	cfg.Tag = fs.Arg(0)
 	if fs.NArg() > 1 {
 		cfg.Instructions = fs.Arg(1)
 	}
 
-	cfg.Version = version
+	cfg.flagSettings = flagSettings(fs, cfg)
 
 	return cfg, nil
 }
 
+// newFlagSet creates a flag set with the options shared by all commands.
+func newFlagSet(cfg *Config, name string, usage func()) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.StringVar(&cfg.Model, "model", "", "")
+	fs.StringVar(&cfg.Model, "m", "", "")
+	fs.StringVar(&cfg.Backend, "backend", llm.BackendClaude, "")
+	fs.StringVar(&cfg.Forge, "forge", "", "")
+	fs.StringVar(&cfg.TagPattern, "tag-pattern", "", "")
+	fs.StringVar(&cfg.Previous, "previous", "", "")
+	fs.StringVar(&cfg.Prereleases, "prereleases", "", "")
+	fs.StringVar(&cfg.Component, "component", "", "")
+	fs.BoolVar(&cfg.NoConfirm, "no-confirm", false, "")
+	fs.BoolVar(&cfg.NoFooter, "no-footer", false, "")
+	fs.BoolVar(&cfg.NoFallback, "no-fallback", false, "")
+	fs.BoolVar(&cfg.NoMerges, "no-merges", false, "")
+	fs.BoolVar(&cfg.FirstParent, "first-parent", false, "")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "")
+	fs.BoolVar(&cfg.Force, "force", false, "")
+	fs.BoolVar(&cfg.Verbose, "verbose", false, "")
+	fs.BoolVar(&cfg.Verbose, "v", false, "")
+	fs.StringVar(&cfg.Changelog, "changelog", "", "")
+	fs.StringVar(&cfg.ChangelogHeading, "changelog-heading", "", "")
+	fs.StringVar(&cfg.ChangelogLink, "changelog-link", "", "")
+	fs.IntVar(&cfg.TokenBudget, "token-budget", 0, "")
+	fs.BoolVar(&cfg.NoCache, "no-cache", false, "")
+	fs.StringVar(&cfg.Diffs, "diffs", "", "")
+	fs.Func("timeout", "", func(v string) error {
+		cfg.Timeout = append(cfg.Timeout, v)
+
+		return nil
+	})
+
+	fs.Usage = usage
+
+	return fs
+}
+
 func printUsage() {
 	var b strings.Builder
 
 	b.WriteString("\n")
-	fmt.Fprintf(&b, "  %s — Generate GitHub release notes using Claude\n", term.BoldCyan("herald"))
+	fmt.Fprintf(&b, "  %s — Generate release notes using Claude\n", term.BoldCyan("herald"))
 	b.WriteString("\n")
 	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("USAGE"))
 	fmt.Fprintf(&b, "    %s %s %s %s\n",
@@ -91,6 +256,30 @@ func printUsage() {
 		term.Yellow("<tag|last>"),
 		term.Yellow("[\"instructions\"]"),
 		term.Dim("[options]"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald"),
+		term.Yellow("backfill"),
+		term.Dim("[options]   (see herald backfill --help)"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald"),
+		term.Yellow("unreleased"),
+		term.Dim("[options] (draft notes for changes since the latest tag)"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald"),
+		term.Yellow("history <tag>"),
+		term.Dim("[options]    (list archived release notes)"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald"),
+		term.Yellow("rollback <tag>"),
+		term.Dim("[--to <n>]  (restore archived release notes)"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald"),
+		term.Yellow("config show"),
+		term.Dim("[options]      (print the effective configuration)"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald"),
+		term.Yellow("cache <action>"),
+		term.Dim("[options]   (stats, prune or clear the summary cache)"))
 	b.WriteString("\n")
 	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("ARGUMENTS"))
 	fmt.Fprintf(&b, "    %s                     Release tag or %s for latest\n",
@@ -99,17 +288,67 @@ func printUsage() {
 		term.Green("instructions"), term.Dim("(optional)"))
 	b.WriteString("\n")
 	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("OPTIONS"))
-	fmt.Fprintf(&b, "    %s %s %s    Claude model alias or full name\n",
+	fmt.Fprintf(&b, "    %s %s %s    Model alias or full name\n",
 		term.Green("-m,"), term.Green("--model"), term.Yellow("<model>"))
 	fmt.Fprintf(&b, "                            %s\n",
 		term.Dim("(e.g. haiku, sonnet, opus)"))
+	fmt.Fprintf(&b, "                            %s\n",
+		term.Dim("or a fallback list, e.g. opus,sonnet,haiku"))
+	fmt.Fprintf(&b, "        %s %s   Model backend %s\n",
+		term.Green("--backend"), term.Yellow("<name>"), term.Dim("(default: claude)"))
+	fmt.Fprintf(&b, "                            %s\n",
+		term.Dim("("+strings.Join(llm.Backends, ", ")+")"))
+	fmt.Fprintf(&b, "        %s %s     Release host %s\n",
+		term.Green("--forge"), term.Yellow("<name>"), term.Dim("(default: detected from origin)"))
+	fmt.Fprintf(&b, "                            %s\n",
+		term.Dim("("+strings.Join(forge.Kinds, ", ")+")"))
+	fmt.Fprintf(&b, "        %s %s\n", term.Green("--tag-pattern"), term.Yellow("<glob>"))
+	fmt.Fprintf(&b, "                            Only consider tags matching a glob %s\n", term.Dim("(e.g. \"v*\")"))
+	fmt.Fprintf(&b, "        %s %s  Previous release by %s\n",
+		term.Green("--previous"), term.Yellow("<order>"), term.Dim(strings.Join(forge.Strategies, ", ")))
+	fmt.Fprintf(&b, "                            %s\n",
+		term.Dim("(default: semver with --forge git, published otherwise)"))
+	fmt.Fprintf(&b, "        %s %s\n", term.Green("--prereleases"), term.Yellow("<policy>"))
+	fmt.Fprintf(&b, "                            Prereleases as previous release %s\n",
+		term.Dim("("+strings.Join(forge.PrereleasePolicies, ", ")+"; default: auto)"))
+	fmt.Fprintf(&b, "        %s %s  Monorepo component %s\n",
+		term.Green("--component"), term.Yellow("<name>"), term.Dim("(default: detected from the tag prefix)"))
+	fmt.Fprintf(&b, "        %s %s         Start of the commit range %s\n",
+		term.Green("--since"), term.Yellow("<ref>"), term.Dim("(default: previous release)"))
+	fmt.Fprintf(&b, "        %s %s         End of the commit range %s\n",
+		term.Green("--until"), term.Yellow("<ref>"), term.Dim("(default: the tag)"))
 	fmt.Fprintf(&b, "    %s %s %s     Save notes to file\n",
 		term.Green("-o,"), term.Green("--output"), term.Yellow("<file>"))
 	fmt.Fprintf(&b, "                            %s\n",
 		term.Dim("(default: <tmpdir>/herald/<repo>-<tag>.md)"))
-	fmt.Fprintf(&b, "        %s        Skip confirmation prompt\n", term.Green("--no-confirm"))
+	fmt.Fprintf(&b, "        %s %s  Also insert the notes into a changelog\n",
+		term.Green("--changelog"), term.Yellow("<file>"))
+	fmt.Fprintf(&b, "                            %s\n", term.Dim("(e.g. CHANGELOG.md; written even with --dry-run)"))
+	fmt.Fprintf(&b, "        %s %s\n", term.Green("--changelog-heading"), term.Yellow("<format>"))
+	fmt.Fprintf(&b, "                            Section heading %s\n", term.Dim("(default: \""+changelog.DefaultHeading+"\")"))
+	fmt.Fprintf(&b, "        %s %s\n", term.Green("--changelog-link"), term.Yellow("<format>"))
+	fmt.Fprintf(&b, "                            Link reference %s\n", term.Dim("(default: \""+changelog.DefaultLink+"\", or none)"))
+	fmt.Fprintf(&b, "        %s        Skip review prompt and update right away\n", term.Green("--no-confirm"))
 	fmt.Fprintf(&b, "        %s         Omit herald attribution footer\n", term.Green("--no-footer"))
+	fmt.Fprintf(&b, "        %s       Fail instead of using template notes when the model fails\n",
+		term.Green("--no-fallback"))
+	fmt.Fprintf(&b, "        %s         Leave out merge commits\n", term.Green("--no-merges"))
+	fmt.Fprintf(&b, "        %s      Follow only the first parent of merges\n", term.Green("--first-parent"))
 	fmt.Fprintf(&b, "        %s           Generate notes but don't update release\n", term.Green("--dry-run"))
+	fmt.Fprintf(&b, "        %s             Overwrite release notes not generated by herald\n", term.Green("--force"))
+	fmt.Fprintf(&b, "        %s %s    Limit a phase, e.g. %s\n",
+		term.Green("--timeout"), term.Yellow("<spec>"), term.Dim("generate=10m"))
+	fmt.Fprintf(&b, "                            %s\n",
+		term.Dim("("+strings.Join(phases, ", ")+", or all; default: fetch=5m,generate=30m,publish=5m)"))
+	fmt.Fprintf(&b, "        %s %s  Summarize commits in batches above this prompt size\n",
+		term.Green("--token-budget"), term.Yellow("<n>"))
+	fmt.Fprintf(&b, "                            %s\n",
+		term.Dim(fmt.Sprintf("(estimated tokens; default: %d, 0 never summarizes)", defaultTokenBudget)))
+	fmt.Fprintf(&b, "        %s          Neither read nor write the commit summary cache\n", term.Green("--no-cache"))
+	fmt.Fprintf(&b, "        %s %s      Embed commit patches in the prompt %s\n",
+		term.Green("--diffs"), term.Yellow("<mode>"), term.Dim("("+strings.Join(git.DiffModes, ", ")+"; default: none)"))
+	fmt.Fprintf(&b, "        %s %s   Output format: text or json %s\n",
+		term.Green("--format"), term.Yellow("<format>"), term.Dim("(json needs --no-confirm or --dry-run)"))
 	fmt.Fprintf(&b, "    %s %s           Detailed output\n",
 		term.Green("-v,"), term.Green("--verbose"))
 	fmt.Fprintf(&b, "        %s           Print version and exit\n", term.Green("--version"))
@@ -118,6 +357,7 @@ func printUsage() {
 	fmt.Fprintf(&b, "    %s\n", term.Dim("herald v1.2.0 --dry-run"))
 	fmt.Fprintf(&b, "    %s\n", term.Dim("herald last \"Very detailed api section\""))
 	fmt.Fprintf(&b, "    %s\n", term.Dim("herald v1.2.0 --no-confirm"))
+	fmt.Fprintf(&b, "    %s\n", term.Dim("herald backfill --from v1.0.0 --to v2.3.0"))
 	b.WriteString("\n")
 
 	fmt.Fprint(os.Stderr, b.String())
@@ -145,11 +385,8 @@ func reorderArgs(args []string) []string {
 		if strings.HasPrefix(arg, "-") {
 			flags = append(flags, arg)
 			// Check if this flag expects a value (non-boolean flags)
-			if strings.HasPrefix(arg, "-o") || strings.HasPrefix(arg, "--output") ||
-				strings.HasPrefix(arg, "-m") || strings.HasPrefix(arg, "--model") {
-				if !strings.Contains(arg, "=") {
-					skipNext = true
-				}
+			if takesValue(arg) && !strings.Contains(arg, "=") {
+				skipNext = true
 			}
 		} else {
 			positional = append(positional, arg)
@@ -159,226 +396,306 @@ func reorderArgs(args []string) []string {
 	return append(flags, positional...)
 }
 
-// Run executes the main workflow.
-func Run(cfg *Config) error {
-	// Verify environment
-	if err := verifyEnvironment(cfg); err != nil {
-		return err
+// takesValue reports whether a flag argument expects a separate value argument.
+func takesValue(arg string) bool {
+	name, _, _ := strings.Cut(arg, "=")
+
+	// The flag package accepts one or two dashes for any flag, e.g. -model and --o
+	name = "-" + strings.TrimLeft(name, "-")
+	if len(name) > 2 {
+		name = "-" + name
 	}
 
-	// Fetch remote tags so CI-created tags are available locally
-	logVerbose(cfg, "Fetching tags...")
+	return slices.Contains(valueFlags, name)
+}
 
-	if err := git.FetchTags(); err != nil {
+// Run executes the workflow selected by cfg.Command. Cancelling ctx stops running
+// commands and makes Run return an interruption error.
+func Run(ctx context.Context, cfg *Config) error {
+	if err := loadSettings(cfg); err != nil {
 		return err
 	}
 
-	// Fetch all releases once
-	logVerbose(cfg, "Fetching releases...")
+	forge.Output = stdout
+
+	switch cfg.Command {
+	case commandBackfill:
+		return interrupted(ctx, runBackfill(ctx, cfg))
+	case commandCache:
+		return runCache(cfg)
+	case commandConfig:
+		return runConfigShow(cfg)
+	case commandHistory:
+		return interrupted(ctx, runHistory(ctx, cfg))
+	case commandRollback:
+		return interrupted(ctx, runRollback(ctx, cfg))
+	case commandUnreleased:
+		return interrupted(ctx, runUnreleased(ctx, cfg))
+	default:
+		if cfg.Format == formatJSON {
+			return runWithReport(ctx, cfg)
+		}
 
-	releases, err := github.ListReleases()
+		return interrupted(ctx, runRelease(ctx, cfg, &report{}))
+	}
+}
+
+// runRelease generates notes for a single release and updates it, recording the outcome in rep.
+func runRelease(ctx context.Context, cfg *Config, rep *report) error {
+	start := time.Now()
+
+	s, err := newSession(ctx, cfg)
 	if err != nil {
 		return err
 	}
 
+	s.stream = true
+	rep.Repo = s.repoInfo.NameWithOwner
+
 	// Resolve "last" to the latest release tag
 	if cfg.Tag == "last" {
 		logVerbose(cfg, "Resolving latest release...")
 
-		latest, err := github.GetLatestRelease(releases)
+		latest, err := forge.GetLatestRelease(s.releases)
 		if err != nil {
 			return err
 		}
 
 		cfg.Tag = latest.TagName
-		fmt.Printf("Latest release: %s\n", term.Cyan(cfg.Tag))
+		rep.Tag = cfg.Tag
+		fmt.Fprintf(stdout, "Latest release: %s\n", term.Cyan(cfg.Tag))
 	}
 
-	// Fetch repo info (used for default output path and changelog link)
-	logVerbose(cfg, "Fetching repository info...")
-
-	repoInfo, err := github.GetRepoInfo()
+	d, err := s.prepare(ctx, cfg.Tag, s.outputPath(cfg.Tag))
 	if err != nil {
 		return err
 	}
 
-	// Set default output path if not specified
-	if cfg.Output == "" {
-		cfg.Output = filepath.Join(tempDir, repoInfo.Name+"-"+cfg.Tag+".md")
+	rep.PreviousTag, rep.PromptPath, rep.OutputPath = d.PrevTag, d.PromptPath, d.Output
+	rep.Timings.PrepareMs = since(start)
+
+	fmt.Fprintf(stdout, "Prompt saved to %s\n", term.Cyan(d.PromptPath))
+
+	if cfg.Verbose {
+		fmt.Fprintln(stdout, term.Dim("\n--- Prompt ---"))
+		fmt.Fprintln(stdout, d.Prompt)
+		fmt.Fprintln(stdout, term.Dim("--- End Prompt ---"))
+		fmt.Fprintln(stdout)
 	}
 
-	logVerbose(cfg, "Finding previous release...")
+	fmt.Fprintf(stdout, "Generating release notes with %s...\n", s.generatorName())
 
-	prevRelease, err := github.FindPreviousRelease(releases, cfg.Tag, git.TagExists)
-	if err != nil {
+	start = time.Now()
+
+	if err := s.generate(ctx, d); err != nil {
 		return err
 	}
 
-	var prevTag string
-	var commitDetails string
+	rep.Notes, rep.Fallback = d.Notes, d.Fallback
+	rep.Timings.GenerateMs = since(start)
 
-	if prevRelease != nil {
-		logVerbose(cfg, "Previous release: %s", prevRelease.TagName)
-		prevTag = prevRelease.TagName
+	fmt.Fprintf(stdout, "Release notes saved to %s\n", term.Cyan(d.Output))
 
-		logVerbose(cfg, "Getting commit details...")
+	printPreview(d)
 
-		commitDetails, err = git.GetCommitDetails(prevTag, cfg.Tag)
-		if err != nil {
+	var current string
+
+	if !s.localOnly() {
+		current, err = s.checkOverwrite(ctx, cfg.Tag)
+		if err != nil && !cfg.DryRun {
 			return err
 		}
-	} else {
-		logVerbose(cfg, "No previous release found, using full history")
-		prevTag = ""
-
-		logVerbose(cfg, "Getting commit details from root...")
 
-		commitDetails, err = git.GetCommitDetailsFromRoot(cfg.Tag)
 		if err != nil {
-			return err
+			fmt.Fprintln(stdout, term.Yellow("Warning: "+err.Error()))
+		} else if strings.TrimSpace(current) != "" {
+			printDiff(d, current)
 		}
 	}
 
-	// Generate prompt and invoke Claude
-	promptText := prompt.Generate(cfg.Tag, prevTag, commitDetails, cfg.Instructions)
-
-	// Save prompt to file
-	promptPath := strings.TrimSuffix(cfg.Output, ".md") + "-prompt.md"
-
-	outputDir := filepath.Dir(promptPath)
-	if err := os.MkdirAll(outputDir, 0o755); err != nil {
-		return errors.Runtime("failed to create output directory", err)
-	}
-
-	if err := os.WriteFile(promptPath, []byte(promptText), 0o644); err != nil {
-		return errors.Runtime("failed to write prompt file", err)
-	}
-
-	fmt.Printf("Prompt saved to %s\n", term.Cyan(promptPath))
-
-	if cfg.Verbose {
-		fmt.Println(term.Dim("\n--- Prompt ---"))
-		fmt.Println(promptText)
-		fmt.Println(term.Dim("--- End Prompt ---"))
-		fmt.Println()
-	}
-
-	// Remove previous release notes file so a stale result is never mistaken for a fresh one
-	_ = os.Remove(cfg.Output)
-
-	fmt.Println("Generating release notes with Claude...")
-
-	notes, err := claude.GenerateNotes(promptText, cfg.Model)
-	if err != nil {
-		return err
-	}
+	// Review unless the release is left untouched or confirmation is skipped
+	if !cfg.DryRun && !cfg.NoConfirm {
+		if err := s.review(ctx, d); err != nil {
+			return err
+		}
 
-	// Append "Full Changelog" link if there's a previous release
-	if prevRelease != nil {
-		notes = appendFullChangelog(notes, repoInfo.NameWithOwner, prevRelease.TagName, cfg.Tag)
+		rep.Notes = d.Notes
 	}
 
-	// Append herald attribution footer
-	if !cfg.NoFooter {
-		notes = appendFooter(notes, cfg.Version)
-	}
+	if path := s.changelogPath(d); path != "" {
+		if err := s.updateChangelog(d); err != nil {
+			return err
+		}
 
-	// Save to file
-	if err := os.WriteFile(cfg.Output, []byte(notes), 0o644); err != nil {
-		return errors.Runtime("failed to write output file", err)
+		fmt.Fprintf(stdout, "Changelog updated: %s\n", term.Cyan(path))
 	}
 
-	fmt.Printf("Release notes saved to %s\n", term.Cyan(cfg.Output))
-
-	// Display preview
-	fmt.Println(term.Dim("\n--- Preview ---"))
-	fmt.Println(notes)
-	fmt.Println(term.Dim("--- End Preview ---"))
-
 	// Handle dry-run
 	if cfg.DryRun {
-		fmt.Println(term.Yellow("\nDry run: release not updated"))
+		fmt.Fprintln(stdout, term.Yellow("\nDry run: release not updated"))
 
 		return nil
 	}
 
-	// Confirm and update
-	if !cfg.NoConfirm {
-		if !confirm("Update release " + cfg.Tag + " with these notes?") {
-			return errors.UserAbort()
-		}
+	if s.localOnly() {
+		fmt.Fprintln(stdout, term.Green("Release notes for "+cfg.Tag+" written to "+d.Output))
+
+		return nil
 	}
 
-	fmt.Println("Updating release...")
+	fmt.Fprintln(stdout, "Updating release...")
 
-	if err := github.UpdateReleaseBody(cfg.Tag, cfg.Output); err != nil {
+	start = time.Now()
+
+	if err := s.publish(ctx, cfg.Tag, d.Output, modelLabel(s.cfg), current, history.ActionUpdate); err != nil {
 		return err
 	}
 
-	fmt.Println(term.Green("Release " + cfg.Tag + " updated successfully"))
+	rep.Updated = true
+	rep.Timings.PublishMs = since(start)
+
+	fmt.Fprintln(stdout, term.Green("Release "+cfg.Tag+" updated successfully"))
 
 	return nil
 }
 
-func verifyEnvironment(cfg *Config) error {
+// verifyEnvironment checks the git repository and forge access, and returns the forge
+// provider to use for the rest of the run.
+func verifyEnvironment(ctx context.Context, cfg *Config) (forge.Provider, error) {
 	logVerbose(cfg, "Verifying git repository...")
 
 	if _, err := git.FindRepoRoot(); err != nil {
-		return err
+		return nil, err
+	}
+
+	provider, err := forge.New(ctx, cfg.Forge)
+	if err != nil {
+		return nil, err
 	}
 
-	logVerbose(cfg, "Verifying gh CLI...")
+	logVerbose(cfg, "Verifying %s access...", provider.Name())
 
-	if err := github.CheckGHAvailable(); err != nil {
-		return err
+	if err := provider.CheckAuth(ctx); err != nil {
+		return nil, err
 	}
 
-	logVerbose(cfg, "Verifying claude CLI...")
+	return provider, nil
+}
 
-	if err := claude.CheckClaudeAvailable(); err != nil {
-		return err
+// checkGenerator verifies the model backend. An unusable backend is replaced by template
+// notes (a nil generator) unless no_fallback is set.
+func checkGenerator(ctx context.Context, cfg *Config, generator llm.Generator) (llm.Generator, error) {
+	if generator == nil {
+		return nil, nil
 	}
 
-	return nil
+	logVerbose(cfg, "Verifying %s backend...", cfg.Backend)
+
+	err := generator.Check(ctx)
+	if err == nil || cfg.NoFallback || ctx.Err() != nil {
+		return generator, err
+	}
+
+	fmt.Fprintln(stdout, term.Yellow(fmt.Sprintf("Warning: %v; using template notes", err)))
+
+	return nil, nil
 }
 
 func logVerbose(cfg *Config, format string, args ...any) {
 	if cfg.Verbose {
 		msg := fmt.Sprintf(format, args...)
-		fmt.Println(term.Dim(msg))
+		fmt.Fprintln(stdout, term.Dim(msg))
 	}
 }
 
-func confirm(message string) bool {
-	fmt.Printf("%s %s ", term.Bold(message), term.Dim("[y/N]:"))
+// ask prints a question with an answer hint and returns the trimmed, lowercased reply.
+// Returns "" if stdin cannot be read or ctx is cancelled while waiting.
+func ask(ctx context.Context, message, hint string) string {
+	fmt.Fprintf(stdout, "%s %s ", term.Bold(message), term.Dim(hint))
 
-	reader := bufio.NewReader(os.Stdin)
+	return strings.ToLower(readLine(ctx))
+}
 
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return false
+// askText prints a question and returns the trimmed reply as typed.
+func askText(ctx context.Context, message string) string {
+	fmt.Fprintf(stdout, "%s ", term.Bold(message))
+
+	return readLine(ctx)
+}
+
+// readLine waits for a line on stdin, giving up with "" when ctx is cancelled,
+// so Ctrl-C at a prompt does not wait for Enter.
+func readLine(ctx context.Context) string {
+	lines := make(chan string, 1)
+
+	go func() {
+		response, err := stdin.ReadString('\n')
+		if err != nil && response == "" {
+			lines <- ""
+
+			return
+		}
+
+		lines <- strings.TrimSpace(response)
+	}()
+
+	select {
+	case line := <-lines:
+		return line
+	case <-ctx.Done():
+		fmt.Fprintln(stdout)
+
+		return ""
 	}
+}
+
+// Line prefixes of the content appended by appendFullChangelog and appendFooter.
+const (
+	fullChangelogPrefix = "**Full Changelog**: "
+	footerPrefix        = "*Release notes generated by [herald v"
+)
 
-	response = strings.TrimSpace(strings.ToLower(response))
+// footerText returns the attribution footer: the custom text if configured
+// (with {version} replaced), or the default herald link.
+func footerText(custom, version string) string {
+	if custom != "" {
+		return strings.ReplaceAll(custom, "{version}", version)
+	}
 
-	return response == "y" || response == "yes"
+	return fmt.Sprintf(footerPrefix+"%s](https://github.com/AndreyAkinshin/herald)*", version)
 }
 
-// appendFooter appends a herald attribution footer to the release notes.
-func appendFooter(notes, version string) string {
-	footer := fmt.Sprintf("*Release notes generated by [herald v%s](https://github.com/AndreyAkinshin/herald)*", version)
+// appendFooter appends an attribution footer to the release notes.
+func appendFooter(notes, footer string) string {
 	trimmed := strings.TrimRight(notes, "\n")
 
 	return trimmed + "\n\n" + footer + "\n"
 }
 
 // appendFullChangelog appends a "Full Changelog" link to the release notes.
-func appendFullChangelog(notes, repoNameWithOwner, prevTag, currentTag string) string {
-	link := fmt.Sprintf("**Full Changelog**: https://github.com/%s/compare/%s...%s",
-		repoNameWithOwner, prevTag, currentTag)
+func appendFullChangelog(notes, compareURL string) string {
+	link := fullChangelogPrefix + compareURL
 
 	// Ensure proper spacing before the link
 	trimmed := strings.TrimRight(notes, "\n")
 
 	return trimmed + "\n\n" + link + "\n"
 }
+
+// trimAppendix removes the "Full Changelog" link and footer added by
+// appendFullChangelog and appendFooter, leaving the generated notes.
+func trimAppendix(notes, footer string) string {
+	lines := strings.Split(strings.TrimRight(notes, "\n"), "\n")
+
+	for len(lines) > 0 {
+		last := strings.TrimSpace(lines[len(lines)-1])
+		if last != "" && last != footer &&
+			!strings.HasPrefix(last, fullChangelogPrefix) && !strings.HasPrefix(last, footerPrefix) {
+			break
+		}
+
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestReorderArgs_empty(t *testing.T) {
@@ -103,6 +104,35 @@ func TestReorderArgs_model_equals(t *testing.T) {
 	}
 }
 
+func TestReorderArgs_single_dash_long_flags(t *testing.T) {
+	got := reorderArgs([]string{"v1.0", "-model", "haiku", "-output", "notes.md", "--o", "x.md"})
+	want := []string{"-model", "haiku", "-output", "notes.md", "--o", "x.md", "v1.0"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseArgs_single_dash_long_flags(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v1.0", "-model", "haiku", "-output", "notes.md"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Tag != "v1.0" || cfg.Model != "haiku" || cfg.Output != "notes.md" {
+		t.Errorf("got tag %q, model %q, output %q", cfg.Tag, cfg.Model, cfg.Output)
+	}
+}
+
+func TestReorderArgs_backend_with_value(t *testing.T) {
+	got := reorderArgs([]string{"v1.0", "--backend", "ollama", "-m", "llama3"})
+	want := []string{"--backend", "ollama", "-m", "llama3", "v1.0"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestParseArgs_version(t *testing.T) {
 	cfg, err := ParseArgs("1.0.0", []string{"--version"})
 	if err != nil {
@@ -151,6 +181,28 @@ func TestParseArgs_with_model(t *testing.T) {
 	}
 }
 
+func TestParseArgs_default_backend(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v1.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Backend != "claude" {
+		t.Errorf("Backend = %q, want %q", cfg.Backend, "claude")
+	}
+}
+
+func TestParseArgs_with_backend(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v1.0", "--backend", "anthropic"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Backend != "anthropic" {
+		t.Errorf("Backend = %q, want %q", cfg.Backend, "anthropic")
+	}
+}
+
 func TestParseArgs_with_instructions(t *testing.T) {
 	cfg, err := ParseArgs("1.0.0", []string{"v1.0", "Focus on API changes"})
 	if err != nil {
@@ -163,7 +215,7 @@ func TestParseArgs_with_instructions(t *testing.T) {
 }
 
 func TestAppendFooter(t *testing.T) {
-	got := appendFooter("Some notes\n\n", "0.1.0")
+	got := appendFooter("Some notes\n\n", footerText("", "0.1.0"))
 
 	if got != "Some notes\n\n*Release notes generated by [herald v0.1.0](https://github.com/AndreyAkinshin/herald)*\n" {
 		t.Errorf("got %q", got)
@@ -171,7 +223,7 @@ func TestAppendFooter(t *testing.T) {
 }
 
 func TestAppendFooter_no_trailing_newline(t *testing.T) {
-	got := appendFooter("Notes", "1.0.0")
+	got := appendFooter("Notes", footerText("", "1.0.0"))
 
 	if got != "Notes\n\n*Release notes generated by [herald v1.0.0](https://github.com/AndreyAkinshin/herald)*\n" {
 		t.Errorf("got %q", got)
@@ -179,10 +231,141 @@ func TestAppendFooter_no_trailing_newline(t *testing.T) {
 }
 
 func TestAppendFullChangelog(t *testing.T) {
-	got := appendFullChangelog("Notes\n", "owner/repo", "v1.0", "v2.0")
+	got := appendFullChangelog("Notes\n", "https://github.com/owner/repo/compare/v1.0...v2.0")
 	want := "Notes\n\n**Full Changelog**: https://github.com/owner/repo/compare/v1.0...v2.0\n"
 
 	if got != want {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
+
+func TestTrimAppendix(t *testing.T) {
+	footer := footerText("", "1.0.0")
+	notes := appendFooter(appendFullChangelog("## Features\n\n- New\n", "https://x/compare/v1...v2"), footer)
+
+	if got := trimAppendix(notes, footer); got != "## Features\n\n- New\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestParseArgs_changelog(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v1.0.0", "--changelog", "CHANGELOG.md", "--changelog-link=none"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Changelog != "CHANGELOG.md" || cfg.ChangelogLink != "none" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestParseArgs_flag_settings(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v1.0", "-m", "opus", "--no-footer", "Be brief"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{"model": "opus", "no_footer": true, "instructions": "Be brief"}
+	if len(cfg.flagSettings.Values) != len(want) {
+		t.Fatalf("got %v, want %v", cfg.flagSettings.Values, want)
+	}
+
+	for k, v := range want {
+		if cfg.flagSettings.Values[k] != v {
+			t.Errorf("%s = %v, want %v", k, cfg.flagSettings.Values[k], v)
+		}
+	}
+}
+
+func TestParseArgs_config_show(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"config", "show", "--backend", "ollama"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Command != commandConfig || cfg.flagSettings.Values["backend"] != "ollama" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestParseArgs_config_unknown_command(t *testing.T) {
+	if _, err := ParseArgs("1.0.0", []string{"config", "edit"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestFooterText_custom(t *testing.T) {
+	if got := footerText("Built with herald {version}", "1.2.0"); got != "Built with herald 1.2.0" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestParseArgs_since_until(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v2.0.0", "--since", "v1.0.0", "--until=main"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Tag != "v2.0.0" || cfg.Since != "v1.0.0" || cfg.Until != "main" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestParseArgs_commit_filter(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v1.0", "--no-merges", "--first-parent"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{"no_merges": true, "first_parent": true}
+	if !reflect.DeepEqual(cfg.flagSettings.Values, want) {
+		t.Errorf("got %v, want %v", cfg.flagSettings.Values, want)
+	}
+}
+
+func TestParseArgs_timeout(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v1.0", "--timeout", "generate=10m", "--timeout=fetch=1m,publish=0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{"timeout": []string{"generate=10m", "fetch=1m,publish=0"}}
+	if !reflect.DeepEqual(cfg.flagSettings.Values, want) {
+		t.Errorf("got %v, want %v", cfg.flagSettings.Values, want)
+	}
+}
+
+func TestParseArgs_cache(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"cache", "prune", "--older-than", "30d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Command != commandCache || cfg.CacheAction != cachePrune || cfg.OlderThan != 30*24*time.Hour {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestParseArgs_cache_default_age(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"cache", "stats"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.OlderThan != defaultCacheAge {
+		t.Errorf("got %v, want %v", cfg.OlderThan, defaultCacheAge)
+	}
+}
+
+func TestParseArgs_cache_invalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"cache"},
+		{"cache", "purge"},
+		{"cache", "prune", "--older-than", "soon"},
+		{"cache", "prune", "--older-than", "0d"},
+	} {
+		if _, err := ParseArgs("1.0.0", args); err == nil {
+			t.Errorf("%q: expected error, got nil", args)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/config"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/forge"
+)
+
+// checkComponent verifies that the component selected with --component is configured.
+func checkComponent(cfg *Config) error {
+	if cfg.Component == "" {
+		return nil
+	}
+
+	if _, ok := cfg.Components[cfg.Component]; ok {
+		return nil
+	}
+
+	names := slices.Sorted(maps.Keys(cfg.Components))
+
+	if len(names) == 0 {
+		return errors.Config("unknown component " + cfg.Component + " (no components are configured)")
+	}
+
+	return errors.Config("unknown component " + cfg.Component + " (configured: " + strings.Join(names, ", ") + ")")
+}
+
+// componentOf returns the component a tag belongs to: the one selected with --component,
+// or else the one matched by matchComponent.
+func componentOf(cfg *Config, tag string) (string, *config.Component) {
+	if cfg.Component != "" {
+		c := cfg.Components[cfg.Component]
+
+		return cfg.Component, &c
+	}
+
+	return matchComponent(cfg.Components, tag)
+}
+
+// matchComponent returns the component with the longest tag prefix of tag, or "" and nil
+// if the tag belongs to no component.
+func matchComponent(components map[string]config.Component, tag string) (string, *config.Component) {
+	var (
+		name string
+		comp *config.Component
+	)
+
+	for _, n := range slices.Sorted(maps.Keys(components)) {
+		c := components[n]
+		if strings.HasPrefix(tag, c.TagPrefix) && (comp == nil || len(c.TagPrefix) > len(comp.TagPrefix)) {
+			name, comp = n, &c
+		}
+	}
+
+	return name, comp
+}
+
+// componentReleases returns the releases of the component selected with --component,
+// or all releases if there is none.
+func componentReleases(cfg *Config, releases []forge.Release) []forge.Release {
+	if cfg.Component == "" {
+		return releases
+	}
+
+	prefix := cfg.Components[cfg.Component].TagPrefix
+
+	return slices.DeleteFunc(slices.Clone(releases), func(r forge.Release) bool {
+		return !strings.HasPrefix(r.TagName, prefix)
+	})
+}
+
+// withoutComponents removes the releases of every component except for the tag itself,
+// so a repository-wide release never follows a component release.
+func withoutComponents(cfg *Config, releases []forge.Release, tag string) []forge.Release {
+	if len(cfg.Components) == 0 {
+		return releases
+	}
+
+	return slices.DeleteFunc(slices.Clone(releases), func(r forge.Release) bool {
+		name, _ := matchComponent(cfg.Components, r.TagName)
+
+		return name != "" && r.TagName != tag
+	})
+}
+
+// safeName makes a tag or ref usable in a file name, e.g. "api-v1.2.0" for "api/v1.2.0".
+func safeName(ref string) string {
+	return strings.NewReplacer("/", "-", "\\", "-", ":", "-").Replace(ref)
+}
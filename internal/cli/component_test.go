@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/AndreyAkinshin/herald/internal/config"
+	"github.com/AndreyAkinshin/herald/internal/forge"
+)
+
+func monorepoConfig() *Config {
+	cfg := &Config{}
+	cfg.Components = map[string]config.Component{
+		"api":      {TagPrefix: "api/", Paths: []string{"services/api/**"}},
+		"api-beta": {TagPrefix: "api/beta/"},
+		"web":      {TagPrefix: "web/"},
+	}
+
+	return cfg
+}
+
+func TestComponentOf(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"api/v1.2.0", "api"},
+		{"api/beta/v0.1.0", "api-beta"},
+		{"web/v3.0.1", "web"},
+		{"v1.0.0", ""},
+	}
+
+	for _, tt := range tests {
+		if got, _ := componentOf(monorepoConfig(), tt.tag); got != tt.want {
+			t.Errorf("componentOf(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestComponentOf_selected(t *testing.T) {
+	cfg := monorepoConfig()
+	cfg.Component = "web"
+
+	name, comp := componentOf(cfg, "HEAD")
+	if name != "web" || comp == nil || comp.TagPrefix != "web/" {
+		t.Errorf("got %q, %+v", name, comp)
+	}
+}
+
+func TestCheckComponent(t *testing.T) {
+	cfg := monorepoConfig()
+
+	cfg.Component = "api"
+	if err := checkComponent(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Component = "docs"
+	if err := checkComponent(cfg); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestComponentReleases(t *testing.T) {
+	releases := []forge.Release{{TagName: "api/v1.0.0"}, {TagName: "web/v1.0.0"}, {TagName: "v1.0.0"}}
+
+	cfg := monorepoConfig()
+	if got := componentReleases(cfg, releases); len(got) != 3 {
+		t.Errorf("without --component: got %v", got)
+	}
+
+	cfg.Component = "web"
+	if got := componentReleases(cfg, releases); len(got) != 1 || got[0].TagName != "web/v1.0.0" {
+		t.Errorf("with --component web: got %v", got)
+	}
+}
+
+func TestWithoutComponents(t *testing.T) {
+	releases := []forge.Release{{TagName: "api/v1.0.0"}, {TagName: "v1.0.0"}, {TagName: "v1.1.0"}}
+
+	got := withoutComponents(monorepoConfig(), releases, "v1.1.0")
+	if len(got) != 2 || got[0].TagName != "v1.0.0" || got[1].TagName != "v1.1.0" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	if got := safeName("api/v1.2.0"); got != "api-v1.2.0" {
+		t.Errorf("got %q", got)
+	}
+}
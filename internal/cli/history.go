@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/forge"
+	"github.com/AndreyAkinshin/herald/internal/history"
+	"github.com/AndreyAkinshin/herald/internal/term"
+)
+
+// historyPreviewWidth is the maximum width of the first-line preview in herald history.
+const historyPreviewWidth = 60
+
+func parseHistoryArgs(cfg *Config, args []string) (*Config, error) {
+	fs := newFlagSet(cfg, "herald "+cfg.Command, printHistoryUsage)
+	if cfg.Command == commandRollback {
+		fs.IntVar(&cfg.RollbackTo, "to", 1, "")
+	}
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return nil, errors.Config(err.Error())
+	}
+
+	switch {
+	case fs.NArg() < 1:
+		return nil, errors.Config("missing required argument: tag")
+	case fs.NArg() > 1:
+		return nil, errors.Config("unexpected argument: " + fs.Arg(1))
+	case cfg.Command == commandRollback && cfg.RollbackTo < 1:
+		return nil, errors.Config("--to must be at least 1")
+	}
+
+	cfg.Tag = fs.Arg(0)
+	cfg.flagSettings = flagSettings(fs, cfg)
+
+	return cfg, nil
+}
+
+func printHistoryUsage() {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s — Archived release notes\n", term.BoldCyan("herald history / rollback"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("USAGE"))
+	fmt.Fprintf(&b, "    %s %s %s\n", term.BoldCyan("herald history"), term.Yellow("<tag>"), term.Dim("[options]"))
+	fmt.Fprintf(&b, "    %s %s %s %s\n",
+		term.BoldCyan("herald rollback"), term.Yellow("<tag>"), term.Yellow("[--to <n>]"), term.Dim("[options]"))
+	b.WriteString("\n")
+	b.WriteString("    Herald archives the previous notes every time it updates a release.\n")
+	b.WriteString("    history lists them, most recent first; rollback restores version n\n")
+	b.WriteString("    (default: 1, the notes before the last update). MODEL is the model that\n")
+	b.WriteString("    wrote the archived notes, if herald did.\n")
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("OPTIONS"))
+	fmt.Fprintf(&b, "        %s %s            Version to restore %s\n",
+		term.Green("--to"), term.Yellow("<n>"), term.Dim("(rollback only)"))
+	fmt.Fprintf(&b, "        %s        Restore without asking\n", term.Green("--no-confirm"))
+	fmt.Fprintf(&b, "        %s           Show the change but don't update the release\n", term.Green("--dry-run"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "    History is stored in %s.\n", term.Dim("$XDG_DATA_HOME/herald/history/<owner>/<repo>"))
+	b.WriteString("\n")
+
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// newHistorySession connects to the forge and opens the history store, without a model backend.
+func newHistorySession(ctx context.Context, cfg *Config) (*session, error) {
+	var (
+		provider forge.Provider
+		repoInfo *forge.RepoInfo
+	)
+
+	err := runPhase(ctx, cfg, phaseFetch, func(ctx context.Context) error {
+		var err error
+
+		if provider, err = verifyEnvironment(ctx, cfg); err != nil {
+			return err
+		}
+
+		repoInfo, err = provider.GetRepoInfo(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := history.Open(repoInfo.NameWithOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{cfg: cfg, provider: provider, repoInfo: repoInfo, history: store}, nil
+}
+
+// runHistory lists the archived bodies of a release.
+func runHistory(ctx context.Context, cfg *Config) error {
+	s, err := newHistorySession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.history.List(cfg.Tag)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(stdout, "No archived notes for %s\n", cfg.Tag)
+
+		return nil
+	}
+
+	printHistory(entries)
+
+	return nil
+}
+
+func printHistory(entries []history.Entry) {
+	modelWidth := len("MODEL")
+	for _, e := range entries {
+		modelWidth = max(modelWidth, len(e.Model))
+	}
+
+	fmt.Fprintln(stdout, term.Bold(fmt.Sprintf("%3s  %-16s  %-8s  %-8s  %-*s  %s",
+		"#", "REPLACED", "HERALD", "ACTION", modelWidth, "MODEL", "NOTES")))
+
+	for i, e := range entries {
+		fmt.Fprintf(stdout, "%3d  %-16s  %-8s  %-8s  %-*s  %s\n",
+			i+1, e.Time.Local().Format("2006-01-02 15:04"), e.HeraldVersion, e.Action,
+			modelWidth, e.Model, term.Dim(bodyPreview(e.Body)))
+	}
+}
+
+// bodyPreview returns the first non-empty line of a release body, shortened for a table.
+func bodyPreview(body string) string {
+	for line := range strings.SplitSeq(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if len(line) > historyPreviewWidth {
+			line = line[:historyPreviewWidth-3] + "..."
+		}
+
+		return line
+	}
+
+	return "(empty)"
+}
+
+// runRollback restores an archived body, archiving the body it replaces.
+func runRollback(ctx context.Context, cfg *Config) error {
+	s, err := newHistorySession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.history.List(cfg.Tag)
+	if err != nil {
+		return err
+	}
+
+	if cfg.RollbackTo > len(entries) {
+		return errors.Config(fmt.Sprintf("%s has %d archived versions, cannot restore #%d",
+			cfg.Tag, len(entries), cfg.RollbackTo))
+	}
+
+	entry := entries[cfg.RollbackTo-1]
+
+	current, err := s.releaseBody(ctx, cfg.Tag)
+	if err != nil {
+		return err
+	}
+
+	d := &draft{
+		Tag:    cfg.Tag,
+		Output: filepath.Join(tempDir, s.repoInfo.Name+"-"+safeName(cfg.Tag)+"-rollback.md"),
+		Notes:  entry.Body,
+	}
+
+	fmt.Fprintf(stdout, "Restoring notes of %s replaced on %s\n",
+		term.Cyan(cfg.Tag), entry.Time.Local().Format("2006-01-02 15:04"))
+	printDiff(d, current)
+
+	if cfg.DryRun {
+		fmt.Fprintln(stdout, term.Yellow("\nDry run: release not updated"))
+
+		return nil
+	}
+
+	if !cfg.NoConfirm {
+		if answer := ask(ctx, "Restore these notes?", "[y/N]:"); answer != "y" && answer != "yes" {
+			return errors.UserAbort()
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.Output), 0o755); err != nil {
+		return errors.Runtime("failed to create output directory", err)
+	}
+
+	if err := os.WriteFile(d.Output, []byte(entry.Body), 0o644); err != nil {
+		return errors.Runtime("failed to write notes file", err)
+	}
+
+	if err := s.publish(ctx, cfg.Tag, d.Output, entry.Model, current, history.ActionRollback); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, term.Green("Release "+cfg.Tag+" restored successfully"))
+
+	return nil
+}
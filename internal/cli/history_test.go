@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/AndreyAkinshin/herald/internal/history"
+)
+
+func TestParseArgs_rollback(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"rollback", "v1.0", "--to", "2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Command != commandRollback || cfg.Tag != "v1.0" || cfg.RollbackTo != 2 {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestParseArgs_rollback_default_version(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"rollback", "v1.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.RollbackTo != 1 {
+		t.Errorf("RollbackTo = %d, want 1", cfg.RollbackTo)
+	}
+}
+
+func TestParseArgs_history_requires_tag(t *testing.T) {
+	if _, err := ParseArgs("1.0.0", []string{"history"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseArgs_history_rejects_to(t *testing.T) {
+	if _, err := ParseArgs("1.0.0", []string{"history", "v1.0", "--to", "2"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestPublish_archives_previous_body(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+
+	if err := os.WriteFile(notes, []byte("new notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Version: "1.2.0"}
+	cfg.Backend, cfg.Model = "anthropic", "opus"
+
+	provider := &fakeProvider{}
+	store := history.New(filepath.Join(dir, "history"))
+	s := &session{cfg: cfg, provider: provider, history: store}
+
+	if err := s.publish(t.Context(), "v1.0", notes, modelLabel(cfg), "old notes", history.ActionUpdate); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(provider.updated) != 1 {
+		t.Fatalf("release updated %d times, want 1", len(provider.updated))
+	}
+
+	entries, err := store.List("v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+
+	e := entries[0]
+	if e.Body != "old notes" || e.HeraldVersion != "1.2.0" || e.Action != history.ActionUpdate ||
+		e.Model != "" || e.NextModel != "anthropic/opus" {
+		t.Errorf("got %+v", e)
+	}
+}
+
+func TestPublish_records_the_model_of_the_archived_body(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	store := history.New(filepath.Join(dir, "history"))
+	s := &session{cfg: &Config{Version: "1.2.0"}, provider: &fakeProvider{}, history: store}
+
+	bySonnet := "- by sonnet\n\n" + footerText("", "1.1.0")
+	byOpus := "- by opus\n\n" + footerText("", "1.2.0")
+
+	steps := []struct{ current, model, action string }{
+		{"hand-written", "claude/sonnet", history.ActionUpdate},
+		{bySonnet, "claude/opus", history.ActionUpdate},
+		{byOpus, "claude/sonnet", history.ActionRollback},
+	}
+
+	for _, step := range steps {
+		if err := s.publish(t.Context(), "v1.0", notes, step.model, step.current, step.action); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	entries, err := store.List("v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, len(entries))
+	for i, e := range entries {
+		got[i] = e.Model
+	}
+
+	if want := []string{"claude/opus", "claude/sonnet", ""}; !slices.Equal(got, want) {
+		t.Errorf("models of the archived bodies = %q, want %q", got, want)
+	}
+}
+
+func TestPublish_failed_update_is_not_archived(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{Version: "1.2.0"}
+
+	store := history.New(filepath.Join(dir, "history"))
+	if err := store.Add(history.Entry{Tag: "v1.0", Action: history.ActionUpdate, Body: "oldest notes"}); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &fakeProvider{updateErr: fmt.Errorf("HTTP 502")}
+	s := &session{cfg: cfg, provider: provider, history: store}
+
+	err := s.publish(t.Context(), "v1.0", filepath.Join(dir, "notes.md"), "", "old notes", history.ActionUpdate)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	entries, err := store.List("v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Body != "oldest notes" {
+		t.Errorf("got %+v, want only the earlier entry", entries)
+	}
+}
+
+func TestBodyPreview(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"\n\n## Features\n- x", "## Features"},
+		{"", "(empty)"},
+	}
+
+	for _, tt := range tests {
+		if got := bodyPreview(tt.body); got != tt.want {
+			t.Errorf("bodyPreview(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
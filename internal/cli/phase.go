@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/config"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// Phases of a run, each limited by its own timeout.
+const (
+	// phaseFetch reads tags, releases and commits.
+	phaseFetch = "fetch"
+	// phaseGenerate asks the model for the notes of one release.
+	phaseGenerate = "generate"
+	// phasePublish reads and updates the release on the forge.
+	phasePublish = "publish"
+)
+
+// phases lists all phases in display order.
+var phases = []string{phaseFetch, phaseGenerate, phasePublish}
+
+// defaultTimeouts apply to the phases the timeout setting leaves out.
+var defaultTimeouts = map[string]time.Duration{
+	phaseFetch:    5 * time.Minute,
+	phaseGenerate: 30 * time.Minute,
+	phasePublish:  5 * time.Minute,
+}
+
+// phaseTimeouts parses the timeout setting. Each entry is either "phase=duration", for
+// one phase, or a bare duration, for all of them; a zero duration disables the timeout.
+// Entries may also be comma-separated, as given to --timeout.
+func phaseTimeouts(settings *config.Settings) (map[string]time.Duration, error) {
+	timeouts := maps.Clone(defaultTimeouts)
+
+	for _, entry := range settings.Timeout {
+		for item := range strings.SplitSeq(entry, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+
+			phase, value, named := strings.Cut(item, "=")
+			if !named {
+				value = phase
+			}
+
+			d, err := time.ParseDuration(strings.TrimSpace(value))
+			if err != nil || d < 0 {
+				return nil, errors.Config(fmt.Sprintf("invalid %s %q: expected a duration such as 10m", config.KeyTimeout, item))
+			}
+
+			if !named {
+				for _, p := range phases {
+					timeouts[p] = d
+				}
+
+				continue
+			}
+
+			phase = strings.TrimSpace(phase)
+			if !slices.Contains(phases, phase) {
+				return nil, errors.Config(fmt.Sprintf("unknown %s phase %q (supported: %s)",
+					config.KeyTimeout, phase, strings.Join(phases, ", ")))
+			}
+
+			timeouts[phase] = d
+		}
+	}
+
+	return timeouts, nil
+}
+
+// runPhase calls fn with ctx limited by the timeout of phase. When the timeout expires,
+// the error is reported as a timeout rather than as whatever failure it caused, and
+// when ctx itself is cancelled (Ctrl-C), as an interruption.
+func runPhase(ctx context.Context, cfg *Config, phase string, fn func(ctx context.Context) error) error {
+	timeout := cfg.timeouts[phase]
+
+	phaseCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		phaseCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := fn(phaseCtx)
+
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return errors.Interrupted()
+	case phaseCtx.Err() != nil:
+		return errors.Timeout(fmt.Sprintf("%s timed out after %v; raise the limit with --timeout %s=<duration>",
+			phase, timeout, phase), nil)
+	default:
+		return err
+	}
+}
+
+// interrupted reports an error caused by cancelling ctx (Ctrl-C or SIGTERM) as an
+// interruption, whatever it was wrapped in.
+func interrupted(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return errors.Interrupted()
+	}
+
+	return err
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/config"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+func TestPhaseTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout []string
+		want    map[string]time.Duration
+	}{
+		{"defaults", nil, defaultTimeouts},
+		{
+			"one phase",
+			[]string{"generate=10m"},
+			map[string]time.Duration{phaseFetch: 5 * time.Minute, phaseGenerate: 10 * time.Minute, phasePublish: 5 * time.Minute},
+		},
+		{
+			"all phases, then one",
+			[]string{"1m", "publish=0"},
+			map[string]time.Duration{phaseFetch: time.Minute, phaseGenerate: time.Minute, phasePublish: 0},
+		},
+		{
+			"comma-separated",
+			[]string{"fetch=30s, generate=1h"},
+			map[string]time.Duration{phaseFetch: 30 * time.Second, phaseGenerate: time.Hour, phasePublish: 5 * time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := phaseTimeouts(&config.Settings{Timeout: tt.timeout})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhaseTimeouts_invalid(t *testing.T) {
+	for _, timeout := range []string{"soon", "generate=soon", "review=1m", "-1m"} {
+		if _, err := phaseTimeouts(&config.Settings{Timeout: []string{timeout}}); err == nil {
+			t.Errorf("%q: expected error, got nil", timeout)
+		}
+	}
+}
+
+func TestRunPhase_timeout(t *testing.T) {
+	cfg := &Config{timeouts: map[string]time.Duration{phaseGenerate: 10 * time.Millisecond}}
+
+	err := runPhase(t.Context(), cfg, phaseGenerate, func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return errors.Runtime("failed to generate notes", ctx.Err())
+	})
+
+	appErr, ok := err.(*errors.AppError)
+	if !ok || appErr.ExitCode != errors.ExitTimeout {
+		t.Errorf("err = %v, want a timeout error", err)
+	}
+}
+
+func TestRunPhase_interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cfg := &Config{timeouts: defaultTimeouts}
+
+	err := runPhase(ctx, cfg, phaseFetch, func(ctx context.Context) error {
+		cancel()
+
+		return errors.Runtime("failed to fetch tags", ctx.Err())
+	})
+
+	appErr, ok := err.(*errors.AppError)
+	if !ok || appErr.ExitCode != errors.ExitUserAbort {
+		t.Errorf("err = %v, want an interruption", err)
+	}
+}
+
+func TestRunPhase_error(t *testing.T) {
+	want := errors.Config("bad ref")
+
+	err := runPhase(t.Context(), &Config{}, phaseFetch, func(context.Context) error { return want })
+	if err != want {
+		t.Errorf("err = %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,778 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/cache"
+	"github.com/AndreyAkinshin/herald/internal/changelog"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/forge"
+	"github.com/AndreyAkinshin/herald/internal/git"
+	"github.com/AndreyAkinshin/herald/internal/history"
+	"github.com/AndreyAkinshin/herald/internal/llm"
+	"github.com/AndreyAkinshin/herald/internal/notes"
+	"github.com/AndreyAkinshin/herald/internal/prompt"
+	"github.com/AndreyAkinshin/herald/internal/retry"
+	"github.com/AndreyAkinshin/herald/internal/term"
+)
+
+// session holds the state shared by all releases processed in one run.
+type session struct {
+	cfg       *Config
+	provider  forge.Provider
+	generator llm.Generator
+	releases  []forge.Release
+	repoInfo  *forge.RepoInfo
+	filter    *git.Filter
+	history   *history.Store
+	// cache holds the commit summaries of earlier runs; nil if disabled.
+	cache *cache.Store
+	// stream shows the model output while it is generated; off when releases are
+	// generated in parallel.
+	stream bool
+}
+
+// draft holds the prompt and generated notes for a single release.
+type draft struct {
+	Tag        string
+	PrevTag    string
+	Output     string
+	PromptPath string
+	Prompt     string
+	Notes      string
+	// Unreleased marks a draft for commits after the latest tag rather than for a release.
+	Unreleased bool
+	// Component is the monorepo component the release belongs to, if any.
+	Component string
+	// Until ends the commit range instead of Tag (--until).
+	Until string
+	// Commits are the commits in the range, for notes rendered without a model.
+	Commits []git.Commit
+	// Fallback is set when the notes were rendered from a template because the model failed.
+	Fallback bool
+	// PromptData are the values the prompt was rendered from.
+	PromptData prompt.Data
+	// Summaries are set, by commit hash, when the prompt is built from the summaries of the
+	// commits: the ones not in the cache are summarized first, in Batches. Both are cleared
+	// once the prompt is rebuilt.
+	Summaries map[string]string
+	Batches   [][]git.Commit
+}
+
+// head returns the end of the commit range: Until if set, otherwise Tag.
+func (d *draft) head() string {
+	if d.Until != "" {
+		return d.Until
+	}
+
+	return d.Tag
+}
+
+// newGenerator creates the model backend, or returns nil for the template backend,
+// which renders notes from the commits without a model.
+func newGenerator(cfg *Config) (llm.Generator, error) {
+	if cfg.Backend == llm.BackendTemplate {
+		return nil, nil
+	}
+
+	return llm.NewChain(cfg.Backend, cfg.Model, llm.Options{
+		Retry: retry.Policy{
+			Attempts:  generateAttempts,
+			BaseDelay: generateRetryWait,
+			MaxDelay:  generateMaxRetryWait,
+			OnRetry: func(err error, wait time.Duration) {
+				fmt.Fprintln(stdout, term.Yellow(fmt.Sprintf("Warning: %s; retrying in %v",
+					firstLine(err), wait.Round(time.Second))))
+			},
+		},
+		OnFallback: func(failed, next llm.Generator, err error) {
+			fmt.Fprintln(stdout, term.Yellow(fmt.Sprintf("Warning: %s failed: %s; falling back to %s",
+				failed.Name(), firstLine(err), next.Name())))
+		},
+	})
+}
+
+// Retries of a model that fails with a transient error, before the next model of the
+// --model list takes over.
+const (
+	generateAttempts     = 3
+	generateRetryWait    = 5 * time.Second
+	generateMaxRetryWait = time.Minute
+)
+
+// firstLine returns the first line of an error message, as error output of tools and
+// APIs can span many.
+func firstLine(err error) string {
+	line, _, _ := strings.Cut(err.Error(), "\n")
+
+	return line
+}
+
+// generatorName returns the backend name used in progress messages.
+func (s *session) generatorName() string {
+	if s.generator == nil {
+		return "the template backend"
+	}
+
+	return s.generator.Name()
+}
+
+// newSession verifies the environment and fetches tags, releases and repository info.
+func newSession(ctx context.Context, cfg *Config) (*session, error) {
+	generator, err := newGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := commitFilter(&cfg.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkComponent(cfg); err != nil {
+		return nil, err
+	}
+
+	s := &session{cfg: cfg, generator: generator, filter: filter, cache: openCache(cfg)}
+
+	if err := runPhase(ctx, cfg, phaseFetch, s.fetch); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// fetch verifies the forge and model backend, then fetches tags, releases and repository info.
+func (s *session) fetch(ctx context.Context) error {
+	cfg := s.cfg
+
+	// Verify environment
+	provider, err := verifyEnvironment(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	generator, err := checkGenerator(ctx, cfg, s.generator)
+	if err != nil {
+		return err
+	}
+
+	// Fetch remote tags so CI-created tags are available locally
+	logVerbose(cfg, "Fetching tags...")
+
+	if err := git.FetchTags(ctx); err != nil {
+		// Plain tag repositories may have no remote at all
+		if cfg.Forge != forge.KindGit || ctx.Err() != nil {
+			return err
+		}
+
+		logVerbose(cfg, "Could not fetch tags: %v", err)
+	}
+
+	// Fetch all releases once
+	logVerbose(cfg, "Fetching releases...")
+
+	releases, err := provider.ListReleases(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Fetch repo info (used for default output path and changelog link)
+	logVerbose(cfg, "Fetching repository info...")
+
+	repoInfo, err := provider.GetRepoInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	store, err := history.Open(repoInfo.NameWithOwner)
+	if err != nil {
+		return err
+	}
+
+	s.provider, s.generator, s.repoInfo, s.history = provider, generator, repoInfo, store
+	s.releases = componentReleases(cfg, forge.FilterReleases(releases, cfg.TagPattern))
+
+	return nil
+}
+
+// outputPath returns the notes file for a tag, expanding {repo} and {tag} in the output setting.
+// Slashes in the tag (as in "api/v1.2.0") are replaced so the notes stay in one directory.
+func (s *session) outputPath(tag string) string {
+	return strings.NewReplacer("{repo}", s.repoInfo.Name, "{tag}", safeName(tag)).Replace(s.cfg.Output)
+}
+
+// localOnly reports whether releases are plain git tags, so notes are only written
+// to files and never published.
+func (s *session) localOnly() bool {
+	return s.cfg.Forge == forge.KindGit
+}
+
+// previousStrategy returns the configured previous-release strategy, defaulting to
+// semantic versions for plain tags and to publish dates for forge releases.
+func (s *session) previousStrategy() string {
+	switch {
+	case s.cfg.Previous != "":
+		return s.cfg.Previous
+	case s.localOnly():
+		return forge.PreviousSemver
+	default:
+		return forge.PreviousPublished
+	}
+}
+
+// prepare finds the previous release, collects commit details and saves the prompt
+// next to the output file.
+func (s *session) prepare(ctx context.Context, tag, output string) (*draft, error) {
+	component, _ := componentOf(s.cfg, tag)
+	if component != "" {
+		logVerbose(s.cfg, "Component: %s", component)
+	}
+
+	d := &draft{Tag: tag, Output: output, Component: component, Until: s.cfg.Until}
+
+	err := runPhase(ctx, s.cfg, phaseFetch, func(ctx context.Context) error {
+		if err := checkRef(ctx, "--until", d.Until); err != nil {
+			return err
+		}
+
+		if err := s.setPrevious(ctx, d); err != nil {
+			return err
+		}
+
+		return s.writePrompt(ctx, d)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// setPrevious sets d.PrevTag to the --since ref, or else to the previous release.
+func (s *session) setPrevious(ctx context.Context, d *draft) error {
+	if s.cfg.Since != "" {
+		if err := checkRef(ctx, "--since", s.cfg.Since); err != nil {
+			return err
+		}
+
+		logVerbose(s.cfg, "Previous release overridden by --since: %s", s.cfg.Since)
+		d.PrevTag = s.cfg.Since
+		s.checkSince(ctx, d)
+
+		return nil
+	}
+
+	logVerbose(s.cfg, "Finding previous release of %s...", d.Tag)
+
+	prevRelease, err := s.findPrevious(ctx, d.Tag, s.previousStrategy())
+	if err != nil {
+		return err
+	}
+
+	if prevRelease != nil {
+		logVerbose(s.cfg, "Previous release: %s", prevRelease.TagName)
+		d.PrevTag = prevRelease.TagName
+
+		if s.previousStrategy() != forge.PreviousTopology {
+			s.checkAncestry(ctx, d)
+		}
+	}
+
+	return nil
+}
+
+// findPrevious finds the release preceding tag with the given strategy, among the
+// releases of the tag's component (or outside all components).
+func (s *session) findPrevious(ctx context.Context, tag, strategy string) (*forge.Release, error) {
+	opts := forge.PreviousOptions{
+		Strategy:     strategy,
+		Prereleases:  s.cfg.Prereleases,
+		TagValidator: func(tag string) bool { return git.TagExists(ctx, tag) },
+	}
+
+	releases := s.releases
+	if _, comp := componentOf(s.cfg, tag); comp != nil {
+		opts.TagPrefix = comp.TagPrefix
+	} else {
+		releases = withoutComponents(s.cfg, releases, tag)
+	}
+
+	return forge.FindPrevious(ctx, releases, tag, opts)
+}
+
+// checkRef verifies that a ref given with a flag resolves to a commit.
+func checkRef(ctx context.Context, flagName, ref string) error {
+	if ref == "" || git.RefExists(ctx, ref) {
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return errors.Config(flagName + " " + ref + " does not resolve to a commit")
+}
+
+// checkSince warns when the --since ref is not an ancestor of the end of the range.
+func (s *session) checkSince(ctx context.Context, d *draft) {
+	isAncestor, err := git.IsAncestor(ctx, d.PrevTag, d.head())
+	if err != nil {
+		logVerbose(s.cfg, "Could not check ancestry of %s: %v", d.head(), err)
+
+		return
+	}
+
+	if !isAncestor {
+		fmt.Fprintln(stdout, term.Yellow(fmt.Sprintf("Warning: --since %s is not an ancestor of %s, "+
+			"so the notes cover every commit since the two diverged", d.PrevTag, d.head())))
+	}
+}
+
+// checkAncestry warns when d.PrevTag is not the nearest release among the ancestors
+// of d.Tag, in which case the commit range may miss or include unrelated changes.
+func (s *session) checkAncestry(ctx context.Context, d *draft) {
+	nearest, err := s.findPrevious(ctx, d.Tag, forge.PreviousTopology)
+	if err != nil {
+		logVerbose(s.cfg, "Could not check ancestry of %s: %v", d.Tag, err)
+
+		return
+	}
+
+	var nearestTag string
+	if nearest != nil {
+		nearestTag = nearest.TagName
+	}
+
+	if nearestTag == d.PrevTag {
+		return
+	}
+
+	isAncestor, err := git.IsAncestor(ctx, d.PrevTag, d.Tag)
+	if err != nil {
+		logVerbose(s.cfg, "Could not check ancestry of %s: %v", d.Tag, err)
+
+		return
+	}
+
+	msg := fmt.Sprintf("Warning: previous release %s is not an ancestor of %s, "+
+		"so the notes cover every commit since the two diverged", d.PrevTag, d.Tag)
+	if isAncestor {
+		msg = fmt.Sprintf("Warning: previous release %s is an ancestor of %s, but not the nearest one",
+			d.PrevTag, d.Tag)
+	}
+
+	if nearestTag != "" {
+		msg += fmt.Sprintf("; the nearest ancestor release is %s (--previous %s)", nearestTag, forge.PreviousTopology)
+	}
+
+	fmt.Fprintln(stdout, term.Yellow(msg))
+}
+
+// reportDropped lists the commits left out by the exclusion rules in verbose mode.
+func (s *session) reportDropped(dropped []git.Dropped) {
+	if len(dropped) == 0 {
+		return
+	}
+
+	logVerbose(s.cfg, "Excluded %d commits:", len(dropped))
+
+	for _, c := range dropped {
+		logVerbose(s.cfg, "  %.7s %s (%s)", c.Hash, c.Subject, c.Reason)
+	}
+}
+
+// conventionalChanges returns the commits that follow Conventional Commits or have a
+// BREAKING CHANGE footer, classified for the prompt.
+func conventionalChanges(commits []git.Commit) []prompt.Change {
+	var changes []prompt.Change
+
+	for _, c := range commits {
+		if cc := c.Conventional; cc != nil {
+			changes = append(changes, prompt.Change{
+				Hash:         c.Hash,
+				Type:         cc.Type,
+				Scope:        cc.Scope,
+				Description:  cc.Description,
+				Breaking:     cc.Breaking,
+				BreakingNote: cc.BreakingNote,
+			})
+		}
+	}
+
+	return changes
+}
+
+// writePrompt collects the commits between d.PrevTag (or the root) and d.head(),
+// builds the prompt and saves it next to the output file.
+func (s *session) writePrompt(ctx context.Context, d *draft) error {
+	var (
+		commits []git.Commit
+		dropped []git.Dropped
+		paths   []string
+		err     error
+	)
+
+	if d.Component != "" {
+		paths = s.cfg.Components[d.Component].Paths
+	}
+
+	if d.PrevTag != "" {
+		logVerbose(s.cfg, "Getting commit details...")
+
+		commits, dropped, err = git.GetCommitDetails(ctx, d.PrevTag, d.head(), paths, s.filter)
+	} else {
+		logVerbose(s.cfg, "No previous release found, using full history")
+		logVerbose(s.cfg, "Getting commit details from root...")
+
+		commits, dropped, err = git.GetCommitDetailsFromRoot(ctx, d.head(), paths, s.filter)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.reportDropped(dropped)
+
+	if s.cfg.Diffs != "" && s.cfg.Diffs != git.DiffsNone {
+		logVerbose(s.cfg, "Getting commit diffs...")
+
+		opts := git.DiffOptions{Mode: s.cfg.Diffs, Paths: paths, FirstParent: s.filter.FirstParent}
+		if err := git.AddDiffs(ctx, commits, opts); err != nil {
+			return err
+		}
+	}
+
+	d.Commits = commits
+
+	d.PromptData = prompt.Data{
+		TargetTag:     d.Tag,
+		PrevTag:       d.PrevTag,
+		Until:         d.Until,
+		CommitDetails: git.FormatCommits(commits),
+		Diffs:         s.cfg.Diffs != "" && s.cfg.Diffs != git.DiffsNone,
+		Tools:         s.cfg.Backend == "" || s.cfg.Backend == llm.BackendClaude,
+		Instructions:  s.cfg.Instructions,
+		Sections:      s.cfg.Sections,
+		Unreleased:    d.Unreleased,
+		Component:     d.Component,
+		Paths:         paths,
+		Changes:       conventionalChanges(commits),
+	}
+	d.Prompt = prompt.Generate(d.PromptData)
+
+	tokens := prompt.EstimateTokens(d.Prompt)
+	logVerbose(s.cfg, "Prompt size: ~%d tokens", tokens)
+
+	s.planSummaries(d, tokens)
+
+	// Save prompt to file
+	d.PromptPath = strings.TrimSuffix(d.Output, ".md") + "-prompt.md"
+
+	outputDir := filepath.Dir(d.PromptPath)
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return errors.Runtime("failed to create output directory", err)
+	}
+
+	if err := os.WriteFile(d.PromptPath, []byte(d.Prompt), 0o644); err != nil {
+		return errors.Runtime("failed to write prompt file", err)
+	}
+
+	return nil
+}
+
+// generate invokes the model and saves the notes, with the "Full Changelog" link
+// and footer appended, to d.Output. Backfill runs it concurrently, so its warnings and
+// progress go to stdout, one whole line at a time.
+func (s *session) generate(ctx context.Context, d *draft) error {
+	// Remove previous release notes file so a stale result is never mistaken for a fresh one
+	_ = os.Remove(d.Output)
+
+	notes, err := s.generateNotes(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	// Append "Full Changelog" link if there's a previous release with a compare page
+	if d.PrevTag != "" && !d.Unreleased {
+		if compareURL := s.provider.CompareURL(s.repoInfo, d.PrevTag, d.head()); compareURL != "" {
+			notes = appendFullChangelog(notes, compareURL)
+		}
+	}
+
+	// Append herald attribution footer
+	if !s.cfg.NoFooter {
+		notes = appendFooter(notes, footerText(s.cfg.Footer, s.cfg.Version))
+	}
+
+	// Save to file
+	if err := os.WriteFile(d.Output, []byte(notes), 0o644); err != nil {
+		return errors.Runtime("failed to write output file", err)
+	}
+
+	d.Notes = notes
+
+	return nil
+}
+
+// generateNotes asks the model for notes. Without a model, or when the model fails,
+// times out or returns nothing (unless no_fallback is set), the notes are rendered
+// from the commits. An interrupted run never falls back.
+func (s *session) generateNotes(ctx context.Context, d *draft) (string, error) {
+	if s.generator == nil {
+		d.Fallback = s.cfg.Backend != llm.BackendTemplate
+
+		return s.renderNotes(d), nil
+	}
+
+	err := s.summarize(ctx, d)
+
+	var notes string
+	if err == nil {
+		notes, err = s.runGenerator(ctx, d.Prompt)
+	}
+
+	if (err == nil && strings.TrimSpace(notes) != "") || s.cfg.NoFallback || ctx.Err() != nil {
+		return notes, err
+	}
+
+	reason := s.generator.Name() + " returned no notes"
+	if err != nil {
+		reason = err.Error()
+	}
+
+	fmt.Fprintln(stdout, term.Yellow(fmt.Sprintf("Warning: %s; using template notes for %s", reason, d.Tag)))
+
+	d.Fallback = true
+
+	return s.renderNotes(d), nil
+}
+
+// runGenerator asks the model for notes within the generate timeout, showing its
+// output as it arrives if streaming is on.
+func (s *session) runGenerator(ctx context.Context, prompt string) (string, error) {
+	var notes string
+
+	err := runPhase(ctx, s.cfg, phaseGenerate, func(ctx context.Context) error {
+		var err error
+
+		if !s.stream {
+			notes, err = llm.GenerateNotes(ctx, s.generator, prompt)
+
+			return err
+		}
+
+		w := &streamWriter{}
+		defer w.finish()
+
+		notes, err = llm.StreamNotes(ctx, s.generator, prompt, w)
+
+		return err
+	})
+
+	return notes, err
+}
+
+// streamWriter prints streamed model output dimmed, to set it apart from the final notes.
+type streamWriter struct {
+	last byte
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		fmt.Fprint(stdout, term.Dim(string(p)))
+		w.last = p[len(p)-1]
+	}
+
+	return len(p), nil
+}
+
+// finish ends the output with a newline, so following messages start on their own line.
+func (w *streamWriter) finish() {
+	if w.last != 0 && w.last != '\n' {
+		fmt.Fprintln(stdout)
+	}
+}
+
+// renderNotes renders notes from the commits of the draft with the built-in template.
+func (s *session) renderNotes(d *draft) string {
+	return notes.Render(notes.Data{Tag: d.Tag, PrevTag: d.PrevTag, Unreleased: d.Unreleased, Commits: d.Commits})
+}
+
+// changelogPath returns the changelog for d: the component's own changelog if it has one,
+// or the one given by --changelog. Returns "" if no changelog is configured.
+func (s *session) changelogPath(d *draft) string {
+	if c, ok := s.cfg.Components[d.Component]; ok && c.Changelog != "" {
+		return c.Changelog
+	}
+
+	return s.cfg.Changelog
+}
+
+// updateChangelog inserts the notes for d into its changelog, creating the file if needed.
+// Component releases are listed by version without the tag prefix.
+func (s *session) updateChangelog(d *draft) error {
+	path := s.changelogPath(d)
+
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Runtime("failed to read changelog", err)
+	}
+
+	entry := changelog.Entry{
+		Tag:   strings.TrimPrefix(d.Tag, s.cfg.Components[d.Component].TagPrefix),
+		Date:  s.releaseDate(d.Tag).Format(time.DateOnly),
+		Notes: trimAppendix(d.Notes, footerText(s.cfg.Footer, s.cfg.Version)),
+	}
+
+	if d.PrevTag != "" {
+		entry.CompareURL = s.provider.CompareURL(s.repoInfo, d.PrevTag, d.head())
+	}
+
+	opts := changelog.Options{Heading: s.cfg.ChangelogHeading, Link: s.cfg.ChangelogLink}
+
+	updated, err := changelog.Update(string(content), entry, opts)
+	if err != nil {
+		return errors.Config(err.Error())
+	}
+
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		return errors.Runtime("failed to write changelog", err)
+	}
+
+	return nil
+}
+
+// releaseDate returns the publish date of the release for tag, or today for
+// drafts and unknown tags.
+func (s *session) releaseDate(tag string) time.Time {
+	for _, r := range s.releases {
+		if r.TagName == tag && !r.PublishedAt.IsZero() {
+			return r.PublishedAt
+		}
+	}
+
+	return time.Now()
+}
+
+// checkOverwrite fetches the current body of the release and refuses to replace
+// non-empty notes that herald did not write, unless --force is set.
+// It returns the current body.
+func (s *session) checkOverwrite(ctx context.Context, tag string) (string, error) {
+	current, err := s.releaseBody(ctx, tag)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(current) == "" || s.isHeraldBody(current) {
+		return current, nil
+	}
+
+	if s.cfg.Force {
+		fmt.Fprintln(stdout, term.Yellow("Replacing release notes of "+tag+" that were not generated by herald (--force)"))
+
+		return current, nil
+	}
+
+	return current, errors.Runtime("release "+tag+" has notes that were not generated by herald; "+
+		"rerun with --force to overwrite them", nil)
+}
+
+// releaseBody fetches the current body of the release within the publish timeout.
+func (s *session) releaseBody(ctx context.Context, tag string) (string, error) {
+	var body string
+
+	err := runPhase(ctx, s.cfg, phasePublish, func(ctx context.Context) error {
+		var err error
+
+		body, err = s.provider.GetReleaseBody(ctx, tag)
+
+		return err
+	})
+
+	return body, err
+}
+
+// isHeraldBody reports whether a release body ends with the herald footer, either
+// the default one or the configured custom footer of any herald version. A footer
+// quoted elsewhere in the body does not count.
+func (s *session) isHeraldBody(body string) bool {
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if strings.HasPrefix(strings.TrimSpace(lines[len(lines)-1]), footerPrefix) {
+		return true
+	}
+
+	footer := strings.TrimSpace(s.cfg.Footer)
+	if footer == "" {
+		return false
+	}
+
+	// A custom footer may span lines: compare as many lines from the end of the body
+	n := strings.Count(footer, "\n") + 1
+	if n > len(lines) {
+		return false
+	}
+
+	parts := strings.Split(footer, "{version}")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+
+	re := regexp.MustCompile(`^` + strings.Join(parts, `\S+`) + `$`)
+
+	return re.MatchString(strings.Join(lines[len(lines)-n:], "\n"))
+}
+
+// publish archives the current release body in the history store, then replaces it
+// with the contents of notesFile, written by model ("" if unknown), within the publish timeout.
+func (s *session) publish(ctx context.Context, tag, notesFile, model, current, action string) error {
+	entry := history.Entry{
+		Time:          time.Now().UTC(),
+		Tag:           tag,
+		HeraldVersion: s.cfg.Version,
+		NextModel:     model,
+		Action:        action,
+		Body:          current,
+	}
+
+	// The current body was written by the model recorded when it was published, unless
+	// it has been replaced by hand since
+	if s.isHeraldBody(current) {
+		entries, err := s.history.List(tag)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) > 0 {
+			entry.Model = entries[0].NextModel
+		}
+	}
+
+	// Archive first, so the current body is never lost, and take the entry back if the
+	// body is not replaced after all
+	if err := s.history.Add(entry); err != nil {
+		return err
+	}
+
+	err := runPhase(ctx, s.cfg, phasePublish, func(ctx context.Context) error {
+		return s.provider.UpdateReleaseBody(ctx, tag, notesFile)
+	})
+	if err != nil {
+		if removeErr := s.history.Remove(entry); removeErr != nil {
+			logVerbose(s.cfg, "Could not remove history entry of %s: %v", tag, removeErr)
+		}
+	}
+
+	return err
+}
+
+// modelLabel describes the backend and models, e.g. "anthropic/opus,sonnet" or "claude".
+func modelLabel(cfg *Config) string {
+	return llm.Label(cfg.Backend, cfg.Model)
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/forge"
+	"github.com/AndreyAkinshin/herald/internal/git"
+	"github.com/AndreyAkinshin/herald/internal/llm"
+	"github.com/AndreyAkinshin/herald/internal/prompt"
+)
+
+// fakeProvider serves a fixed release body and records updates.
+type fakeProvider struct {
+	body    string
+	updated []string
+	// updateErr, if set, fails every update.
+	updateErr error
+}
+
+func (f *fakeProvider) Name() string                                          { return "Fake" }
+func (f *fakeProvider) CheckAuth(context.Context) error                       { return nil }
+func (f *fakeProvider) ListReleases(context.Context) ([]forge.Release, error) { return nil, nil }
+func (f *fakeProvider) GetRepoInfo(context.Context) (*forge.RepoInfo, error) {
+	return &forge.RepoInfo{}, nil
+}
+func (f *fakeProvider) GetReleaseBody(context.Context, string) (string, error) { return f.body, nil }
+func (f *fakeProvider) CompareURL(*forge.RepoInfo, string, string) string      { return "" }
+
+func (f *fakeProvider) UpdateReleaseBody(_ context.Context, tag, _ string) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+
+	f.updated = append(f.updated, tag)
+
+	return nil
+}
+
+func TestCheckOverwrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		footer  string
+		force   bool
+		wantErr bool
+	}{
+		{"empty body", "  \n", "", false, false},
+		{"herald body", "- x\n\n" + footerText("", "0.3.0"), "", false, false},
+		{"custom footer", "- x\n\n_Made by herald 0.1.0_\n", "_Made by herald {version}_", false, false},
+		{"hand-written", "Carefully written notes", "", false, true},
+		{"quoting the footer", "Notes once " + footerText("", "0.3.0") + "\n\nnow by hand", "", false, true},
+		{"custom footer mid-body", "_Made by herald 0.1.0_\n\nthen by hand", "_Made by herald {version}_", false, true},
+		{"custom footer with more text", "- x\n\n_Made by herald 0.1.0_ and by hand", "_Made by herald {version}_",
+			false, true},
+		{"multi-line custom footer", "- x\n\n---\nby herald 0.1.0\n", "---\nby herald {version}", false, false},
+		{"hand-written with force", "Carefully written notes", "", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Force: tt.force}
+			cfg.Footer = tt.footer
+
+			s := &session{cfg: cfg, provider: &fakeProvider{body: tt.body}}
+
+			current, err := s.checkOverwrite(t.Context(), "v1.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if current != tt.body {
+				t.Errorf("current = %q, want %q", current, tt.body)
+			}
+		})
+	}
+}
+
+func TestPreviousStrategy(t *testing.T) {
+	tests := []struct {
+		forge    string
+		previous string
+		want     string
+	}{
+		{"", "", forge.PreviousPublished},
+		{forge.KindGitHub, "", forge.PreviousPublished},
+		{forge.KindGit, "", forge.PreviousSemver},
+		{forge.KindGit, forge.PreviousTopology, forge.PreviousTopology},
+		{forge.KindGitLab, forge.PreviousSemver, forge.PreviousSemver},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{}
+		cfg.Forge, cfg.Previous = tt.forge, tt.previous
+
+		s := &session{cfg: cfg}
+		if got := s.previousStrategy(); got != tt.want {
+			t.Errorf("forge %q, previous %q: got %q, want %q", tt.forge, tt.previous, got, tt.want)
+		}
+	}
+}
+
+func TestDraftHead(t *testing.T) {
+	if got := (&draft{Tag: "v2.0.0"}).head(); got != "v2.0.0" {
+		t.Errorf("got %q, want the tag", got)
+	}
+
+	if got := (&draft{Tag: "v2.0.0", Until: "main"}).head(); got != "main" {
+		t.Errorf("got %q, want the --until ref", got)
+	}
+}
+
+func TestConventionalChanges(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "aaa", Message: "Update README"},
+		{Hash: "bbb", Conventional: &git.Conventional{Type: "feat", Scope: "api", Description: "add x", Breaking: true}},
+	}
+
+	want := []prompt.Change{{Hash: "bbb", Type: "feat", Scope: "api", Description: "add x", Breaking: true}}
+	if got := conventionalChanges(commits); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestGenerateNotes_fallback(t *testing.T) {
+	commits := []git.Commit{{Hash: "abc1234567", Message: "fix: crash", Conventional: git.ParseConventional("fix: crash")}}
+
+	tests := []struct {
+		name       string
+		generator  *fakeGenerator
+		noFallback bool
+		wantErr    bool
+		wantNotes  string
+		fallback   bool
+	}{
+		{"model notes", &fakeGenerator{notes: "## Bug Fixes\n\n- Crash\n"}, false, false, "- Crash", false},
+		{"model error", &fakeGenerator{err: errors.Runtime("rate limited", nil)}, false, false, "- crash (abc1234)", true},
+		{"empty notes", &fakeGenerator{notes: "  \n"}, false, false, "- crash (abc1234)", true},
+		{"no fallback", &fakeGenerator{err: errors.Runtime("rate limited", nil)}, true, true, "", false},
+		{"template backend", nil, false, false, "- crash (abc1234)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			cfg.NoFallback = tt.noFallback
+
+			s := &session{cfg: cfg}
+			if tt.generator != nil {
+				s.generator = tt.generator
+			} else {
+				cfg.Backend = llm.BackendTemplate
+			}
+
+			d := &draft{Tag: "v1.1.0", PrevTag: "v1.0.0", Commits: commits}
+
+			got, err := s.generateNotes(t.Context(), d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !strings.Contains(got, tt.wantNotes) || d.Fallback != tt.fallback {
+				t.Errorf("notes = %q, fallback = %v", got, d.Fallback)
+			}
+		})
+	}
+}
+
+func TestGenerateNotes_interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	s := &session{cfg: &Config{}, generator: &fakeGenerator{err: errors.Runtime("killed", nil)}}
+	d := &draft{Tag: "v1.1.0"}
+
+	if _, err := s.generateNotes(ctx, d); err == nil || d.Fallback {
+		t.Errorf("err = %v, fallback = %v, want an error without fallback", err, d.Fallback)
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// Output formats (values of the --format flag).
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// report is the result of a release run, printed as a single JSON document by --format json.
+type report struct {
+	Tag         string `json:"tag"`
+	PreviousTag string `json:"previousTag"`
+	Repo        string `json:"repo"`
+	PromptPath  string `json:"promptPath"`
+	OutputPath  string `json:"outputPath"`
+	Notes       string `json:"notes"`
+	Updated     bool   `json:"updated"`
+	// Fallback is set when the notes were rendered from a template because the model failed.
+	Fallback bool          `json:"fallback"`
+	Timings  reportTimings `json:"timings"`
+	Error    *reportError  `json:"error"`
+}
+
+// reportTimings holds the duration of each phase in milliseconds.
+type reportTimings struct {
+	PrepareMs  int64 `json:"prepareMs"`
+	GenerateMs int64 `json:"generateMs"`
+	PublishMs  int64 `json:"publishMs"`
+	TotalMs    int64 `json:"totalMs"`
+}
+
+type reportError struct {
+	Message  string `json:"message"`
+	Category string `json:"category"`
+	ExitCode int    `json:"exitCode"`
+}
+
+// runWithReport runs a release and prints its report as JSON to stdout.
+// Human-readable output is discarded, or sent to stderr with --verbose.
+func runWithReport(ctx context.Context, cfg *Config) error {
+	var sink io.Writer = os.Stderr
+	if !cfg.Verbose {
+		devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			return errors.Runtime("failed to open "+os.DevNull, err)
+		}
+		defer devNull.Close()
+
+		sink = devNull
+	}
+
+	restore := stdout.redirect(sink)
+	defer restore()
+
+	rep := &report{Tag: cfg.Tag}
+	start := time.Now()
+
+	err := interrupted(ctx, runRelease(ctx, cfg, rep))
+
+	rep.Timings.TotalMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		rep.Error = newReportError(err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	if encErr := enc.Encode(rep); encErr != nil && err == nil {
+		return errors.Runtime("failed to write JSON output", encErr)
+	}
+
+	return err
+}
+
+func newReportError(err error) *reportError {
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) {
+		appErr = errors.Runtime(err.Error(), nil)
+	}
+
+	return &reportError{Message: err.Error(), Category: appErr.Category(), ExitCode: appErr.ExitCode}
+}
+
+// since returns the milliseconds elapsed since start.
+func since(start time.Time) int64 {
+	return time.Since(start).Milliseconds()
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+func TestParseArgs_format_json(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"v1.0", "--format", "json", "--no-confirm"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Format != formatJSON {
+		t.Errorf("Format = %q, want %q", cfg.Format, formatJSON)
+	}
+}
+
+func TestParseArgs_format_json_requires_non_interactive(t *testing.T) {
+	if _, err := ParseArgs("1.0.0", []string{"v1.0", "--format=json"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseArgs_unknown_format(t *testing.T) {
+	if _, err := ParseArgs("1.0.0", []string{"v1.0", "--format", "xml"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestOutput_redirect(t *testing.T) {
+	var first, second strings.Builder
+
+	out := &output{w: &first}
+	restore := out.redirect(&second)
+	fmt.Fprint(out, "during")
+	restore()
+	fmt.Fprint(out, "after")
+
+	if first.String() != "after" || second.String() != "during" {
+		t.Errorf("got %q and %q", first.String(), second.String())
+	}
+}
+
+func TestNewReportError(t *testing.T) {
+	got := newReportError(errors.Config("bad flag"))
+	if got.Category != "config" || got.ExitCode != errors.ExitConfig || got.Message != "bad flag" {
+		t.Errorf("got %+v", got)
+	}
+
+	got = newReportError(fmt.Errorf("backfill: %w", errors.Timeout("model timed out", nil)))
+	if got.Category != "timeout" || got.ExitCode != errors.ExitTimeout {
+		t.Errorf("wrapped error: got %+v", got)
+	}
+
+	got = newReportError(fmt.Errorf("plain"))
+	if got.Category != "runtime" || got.ExitCode != errors.ExitRuntime {
+		t.Errorf("got %+v", got)
+	}
+}
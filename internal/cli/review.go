@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/diff"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/prompt"
+	"github.com/AndreyAkinshin/herald/internal/term"
+)
+
+// review lets the user refine the draft until they accept it: edit it in $EDITOR,
+// regenerate it with feedback, or compare it with the current release body.
+// Returns a user abort error if the user quits.
+func (s *session) review(ctx context.Context, d *draft) error {
+	// Feedback always refines the latest draft against the original prompt
+	original := d.Prompt
+
+	question := "Update release " + d.Tag + "?"
+	if s.localOnly() {
+		question = "Keep notes for " + d.Tag + "?"
+	}
+
+	for {
+		switch ask(ctx, question, "[a]ccept/[e]dit/[r]egenerate/[d]iff/[q]uit:") {
+		case "a", "accept", "y", "yes":
+			return nil
+		case "e", "edit":
+			if err := s.edit(d); err != nil {
+				return err
+			}
+
+			printPreview(d)
+		case "r", "regenerate":
+			if s.generator == nil {
+				fmt.Fprintln(stdout, term.Yellow("The template backend cannot take feedback, edit the notes instead"))
+
+				continue
+			}
+
+			feedback := askText(ctx, "Feedback for the model:")
+			if feedback == "" {
+				fmt.Fprintln(stdout, term.Yellow("No feedback given, keeping the current draft"))
+
+				continue
+			}
+
+			d.Prompt = prompt.Refine(original, trimAppendix(d.Notes, footerText(s.cfg.Footer, s.cfg.Version)), feedback)
+
+			fmt.Fprintf(stdout, "Regenerating release notes with %s...\n", s.generatorName())
+
+			if err := s.generate(ctx, d); err != nil {
+				return err
+			}
+
+			printPreview(d)
+		case "d", "diff":
+			current, err := s.releaseBody(ctx, d.Tag)
+			if err != nil {
+				return err
+			}
+
+			printDiff(d, current)
+		case "", "q", "quit":
+			return errors.UserAbort()
+		default:
+			fmt.Fprintln(stdout, term.Yellow("Unknown choice"))
+		}
+	}
+}
+
+// edit opens the notes file in the user's editor and reloads it.
+func (s *session) edit(d *draft) error {
+	args := strings.Fields(editorCommand())
+	args = append(args, d.Output)
+
+	// Not killed on Ctrl-C: editors handle it themselves, and killing one would lose the edits
+	cmd := exec.Command(args[0], args[1:]...) //nolint:noctx // see above
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return errors.Runtime("editor failed", err)
+	}
+
+	notes, err := os.ReadFile(d.Output)
+	if err != nil {
+		return errors.Runtime("failed to read edited notes", err)
+	}
+
+	d.Notes = string(notes)
+
+	return nil
+}
+
+// editorCommand returns $VISUAL or $EDITOR, falling back to a platform default.
+func editorCommand() string {
+	if editor := firstNonEmpty(os.Getenv("VISUAL"), os.Getenv("EDITOR")); editor != "" {
+		return editor
+	}
+
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+
+	return "vi"
+}
+
+// printDiff prints a colored unified diff from the current release body to the draft.
+func printDiff(d *draft, current string) {
+	patch := diff.Unified("current "+d.Tag, "new "+d.Tag, current, d.Notes)
+	if patch == "" {
+		fmt.Fprintln(stdout, term.Dim("No changes compared to the current release notes"))
+
+		return
+	}
+
+	fmt.Fprintln(stdout, term.Dim("\n--- Changes to release "+d.Tag+" ---"))
+	fmt.Fprint(stdout, colorDiff(patch))
+	fmt.Fprintln(stdout, term.Dim("--- End Changes ---"))
+}
+
+// colorDiff colors the removed, added and hunk header lines of a unified diff.
+func colorDiff(patch string) string {
+	lines := strings.SplitAfter(patch, "\n")
+
+	for i, l := range lines {
+		text := strings.TrimSuffix(l, "\n")
+
+		switch {
+		case strings.HasPrefix(l, "---"), strings.HasPrefix(l, "+++"):
+			text = term.Bold(text)
+		case strings.HasPrefix(l, "@@"):
+			text = term.Cyan(text)
+		case strings.HasPrefix(l, "-"):
+			text = term.Red(text)
+		case strings.HasPrefix(l, "+"):
+			text = term.Green(text)
+		}
+
+		if strings.HasSuffix(l, "\n") {
+			text += "\n"
+		}
+
+		lines[i] = text
+	}
+
+	return strings.Join(lines, "")
+}
+
+func printPreview(d *draft) {
+	fmt.Fprintln(stdout, term.Dim("\n--- Preview ---"))
+	fmt.Fprintln(stdout, d.Notes)
+	fmt.Fprintln(stdout, term.Dim("--- End Preview ---"))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// fakeGenerator returns canned notes (or err) and records the prompts it receives.
+// It may be called concurrently.
+type fakeGenerator struct {
+	notes   string
+	err     error
+	prompts []string
+	mu      sync.Mutex
+}
+
+func (f *fakeGenerator) Name() string { return "fake" }
+
+func (f *fakeGenerator) Check(context.Context) error { return nil }
+
+func (f *fakeGenerator) Generate(_ context.Context, prompt string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.prompts = append(f.prompts, prompt)
+
+	return f.notes, f.err
+}
+
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+
+	old := stdin
+	stdin = bufio.NewReader(strings.NewReader(input))
+
+	t.Cleanup(func() { stdin = old })
+}
+
+func reviewSession(t *testing.T, g *fakeGenerator) (*session, *draft) {
+	t.Helper()
+
+	cfg := &Config{Version: "1.0.0"}
+	cfg.NoFooter = true
+
+	d := &draft{
+		Tag:    "v1.0",
+		Output: filepath.Join(t.TempDir(), "notes.md"),
+		Prompt: "original prompt",
+		Notes:  "## Features\n\n- First draft\n",
+	}
+
+	return &session{cfg: cfg, generator: g}, d
+}
+
+func TestReview_regenerate_with_feedback(t *testing.T) {
+	withStdin(t, "r\nAlso mention the CLI\na\n")
+
+	g := &fakeGenerator{notes: "## Features\n\n- Second draft\n"}
+	s, d := reviewSession(t, g)
+
+	if err := s.review(t.Context(), d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(g.prompts) != 1 {
+		t.Fatalf("got %d generations, want 1", len(g.prompts))
+	}
+
+	for _, want := range []string{"original prompt", "- First draft", "Also mention the CLI"} {
+		if !strings.Contains(g.prompts[0], want) {
+			t.Errorf("prompt is missing %q", want)
+		}
+	}
+
+	if !strings.Contains(d.Notes, "Second draft") {
+		t.Errorf("notes = %q", d.Notes)
+	}
+}
+
+func TestReview_quit(t *testing.T) {
+	withStdin(t, "x\nq\n")
+
+	s, d := reviewSession(t, &fakeGenerator{})
+
+	err := s.review(t.Context(), d)
+
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.ExitCode != errors.ExitUserAbort {
+		t.Errorf("got %v, want user abort", err)
+	}
+}
+
+func TestReview_eof_aborts(t *testing.T) {
+	withStdin(t, "")
+
+	s, d := reviewSession(t, &fakeGenerator{})
+
+	if err := s.review(t.Context(), d); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/changelog"
+	"github.com/AndreyAkinshin/herald/internal/config"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/forge"
+	"github.com/AndreyAkinshin/herald/internal/git"
+	"github.com/AndreyAkinshin/herald/internal/llm"
+	"github.com/AndreyAkinshin/herald/internal/term"
+)
+
+// flagKeys maps command-line flags to the settings they override.
+var flagKeys = map[string]string{
+	"model":             config.KeyModel,
+	"m":                 config.KeyModel,
+	"backend":           config.KeyBackend,
+	"forge":             config.KeyForge,
+	"tag-pattern":       config.KeyTagPattern,
+	"previous":          config.KeyPrevious,
+	"prereleases":       config.KeyPrereleases,
+	"output":            config.KeyOutput,
+	"o":                 config.KeyOutput,
+	"changelog":         config.KeyChangelog,
+	"changelog-heading": config.KeyChangelogHeading,
+	"changelog-link":    config.KeyChangelogLink,
+	"no-footer":         config.KeyNoFooter,
+	"no-fallback":       config.KeyNoFallback,
+	"no-merges":         config.KeyNoMerges,
+	"first-parent":      config.KeyFirstParent,
+	"timeout":           config.KeyTimeout,
+	"token-budget":      config.KeyTokenBudget,
+	"no-cache":          config.KeyNoCache,
+	"diffs":             config.KeyDiffs,
+}
+
+// defaultSettings returns the values used when no other source sets them.
+func defaultSettings() config.Layer {
+	return config.Layer{Source: config.SourceDefault, Values: map[string]any{
+		config.KeyBackend:          llm.BackendClaude,
+		config.KeyPrereleases:      forge.PrereleasesAuto,
+		config.KeyOutput:           filepath.Join(tempDir, "{repo}-{tag}.md"),
+		config.KeyChangelogHeading: changelog.DefaultHeading,
+		config.KeyChangelogLink:    changelog.DefaultLink,
+		config.KeyTokenBudget:      defaultTokenBudget,
+		config.KeyDiffs:            git.DiffsNone,
+	}}
+}
+
+// flagSettings collects the settings explicitly given on the command line,
+// including the instructions argument.
+func flagSettings(fs *flag.FlagSet, cfg *Config) config.Layer {
+	values := map[string]any{}
+
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			values[key] = cfg.Get(key)
+		}
+	})
+
+	if cfg.Instructions != "" {
+		values[config.KeyInstructions] = cfg.Instructions
+	}
+
+	return config.Layer{Source: config.SourceFlags, Values: values}
+}
+
+// loadSettings merges, in order of increasing precedence, the defaults, the user
+// config, the repository config, HERALD_* environment variables and the command line.
+func loadSettings(cfg *Config) error {
+	user, err := config.LoadUser()
+	if err != nil {
+		return err
+	}
+
+	layers := []config.Layer{defaultSettings(), user}
+
+	if root, err := git.FindRepoRoot(); err == nil {
+		repo, err := config.LoadRepo(root)
+		if err != nil {
+			return err
+		}
+
+		layers = append(layers, repo)
+	}
+
+	layers = append(layers, config.EnvLayer(), cfg.flagSettings)
+
+	settings, err := config.Merge(layers...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := commitFilter(settings); err != nil {
+		return err
+	}
+
+	if cfg.timeouts, err = phaseTimeouts(settings); err != nil {
+		return err
+	}
+
+	if err := git.CheckDiffMode(settings.Diffs); err != nil {
+		return err
+	}
+
+	cfg.Settings = *settings
+
+	return nil
+}
+
+// commitFilter builds the commit exclusion rules from the exclude, exclude_authors,
+// exclude_paths, no_merges and first_parent settings.
+func commitFilter(settings *config.Settings) (*git.Filter, error) {
+	subjects, err := compilePatterns(config.KeyExclude, settings.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	authors, err := compilePatterns(config.KeyExcludeAuthors, settings.ExcludeAuthors)
+	if err != nil {
+		return nil, err
+	}
+
+	return &git.Filter{
+		Subjects:    subjects,
+		Authors:     authors,
+		Paths:       settings.ExcludePaths,
+		Merges:      settings.NoMerges,
+		FirstParent: settings.FirstParent,
+	}, nil
+}
+
+// compilePatterns compiles the regular expressions of a list setting.
+func compilePatterns(key string, patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Config(fmt.Sprintf("invalid %s pattern %q: %v", key, p, err))
+		}
+
+		res = append(res, re)
+	}
+
+	return res, nil
+}
+
+func parseConfigArgs(cfg *Config, args []string) (*Config, error) {
+	fs := newFlagSet(cfg, "herald config", printConfigUsage)
+	fs.StringVar(&cfg.Output, "output", "", "")
+	fs.StringVar(&cfg.Output, "o", "", "")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return nil, errors.Config(err.Error())
+	}
+
+	switch {
+	case fs.NArg() == 0:
+		return nil, errors.Config("missing config command (expected: show)")
+	case fs.Arg(0) != "show":
+		return nil, errors.Config("unknown config command: " + fs.Arg(0))
+	case fs.NArg() > 1:
+		return nil, errors.Config("unexpected argument: " + fs.Arg(1))
+	}
+
+	cfg.flagSettings = flagSettings(fs, cfg)
+
+	return cfg, nil
+}
+
+func printConfigUsage() {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s — Show the effective configuration\n", term.BoldCyan("herald config show"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("USAGE"))
+	fmt.Fprintf(&b, "    %s %s\n", term.BoldCyan("herald config show"), term.Dim("[options]"))
+	b.WriteString("\n")
+	b.WriteString("    Prints every setting with the source it came from. Settings are read from\n")
+	b.WriteString("    (highest precedence first): flags, HERALD_* environment variables,\n")
+	b.WriteString("    .herald.toml or .herald.yaml in the repository root, and\n")
+	b.WriteString("    config.toml or config.yaml in $XDG_CONFIG_HOME/herald.\n")
+	b.WriteString("\n")
+
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// runConfigShow prints the merged settings and where each value came from.
+func runConfigShow(cfg *Config) error {
+	width := 0
+	for _, key := range config.Keys {
+		width = max(width, len(key))
+	}
+
+	for _, key := range config.Keys {
+		source, ok := cfg.Sources[key]
+		if !ok {
+			fmt.Fprintf(stdout, "%-*s  %s\n", width, key, term.Dim("(unset)"))
+
+			continue
+		}
+
+		if source == config.SourceEnv {
+			source += " " + config.EnvName(key)
+		}
+
+		fmt.Fprintf(stdout, "%-*s  %s  %s\n", width, key, term.Cyan(config.Format(cfg.Get(key))), term.Dim("("+source+")"))
+	}
+
+	return nil
+}
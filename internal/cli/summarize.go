@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/AndreyAkinshin/herald/internal/cache"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/git"
+	"github.com/AndreyAkinshin/herald/internal/llm"
+	"github.com/AndreyAkinshin/herald/internal/prompt"
+	"github.com/AndreyAkinshin/herald/internal/term"
+)
+
+const (
+	// defaultTokenBudget leaves room for the response and the git commands the model
+	// runs within a 200k-token context window.
+	defaultTokenBudget = 150_000
+
+	// summaryJobs is the number of batches summarized at the same time.
+	summaryJobs = 4
+
+	// maxBatchCommits keeps the one-line summaries of a batch well within the response
+	// size limits of the models.
+	maxBatchCommits = 200
+)
+
+// commitBatches splits commits, in order, into batches of at most maxBatchCommits whose
+// details stay within limit estimated tokens. A commit too large for a batch of its own
+// loses its diff, then has its file list cut short.
+func commitBatches(commits []git.Commit, limit int) [][]git.Commit {
+	var (
+		batches [][]git.Commit
+		batch   []git.Commit
+		size    int
+	)
+
+	for _, c := range commits {
+		tokens := prompt.EstimateTokens(git.FormatCommits([]git.Commit{c}))
+		if tokens > limit && c.Diff != "" {
+			// The diff goes first: the model can still run git show
+			c.Diff = ""
+			tokens = prompt.EstimateTokens(git.FormatCommits([]git.Commit{c}))
+		}
+
+		if tokens > limit {
+			// The cut commit fills a batch of its own
+			c, tokens = trimStat(c, limit), limit
+		}
+
+		if len(batch) > 0 && (size+tokens > limit || len(batch) == maxBatchCommits) {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+
+		batch = append(batch, c)
+		size += tokens
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+// trimStat cuts the changed files of a commit, such as one that vendors a dependency,
+// until the commit fits in limit estimated tokens.
+func trimStat(c git.Commit, limit int) git.Commit {
+	lines := strings.Split(c.Stat, "\n")
+	budget := limit - prompt.EstimateTokens(git.FormatCommits([]git.Commit{{Hash: c.Hash, Message: c.Message}}))
+
+	kept := 0
+	for _, line := range lines {
+		budget -= prompt.EstimateTokens(line + "\n")
+		if budget < 0 {
+			break
+		}
+
+		kept++
+	}
+
+	if kept < len(lines) {
+		c.Stat = strings.Join(lines[:kept], "\n") + fmt.Sprintf("\n... %d more lines", len(lines)-kept)
+	}
+
+	return c
+}
+
+// planSummaries decides whether the prompt of d, of tokens estimated tokens, is built from
+// the summaries of its commits: a prompt over the budget is, and so is every prompt when the
+// cache keeps the summaries for later runs. It then sets d.Summaries to those in the cache
+// and d.Batches to the other commits. Template notes need no prompt.
+func (s *session) planSummaries(d *draft, tokens int) {
+	over := s.cfg.TokenBudget > 0 && tokens > s.cfg.TokenBudget
+	if s.generator == nil || s.cfg.TokenBudget <= 0 || (!over && s.cache == nil) {
+		return
+	}
+
+	d.Summaries = s.cachedSummaries(d.Commits)
+
+	var pending []git.Commit
+
+	for _, c := range d.Commits {
+		if _, ok := d.Summaries[c.Hash]; !ok {
+			pending = append(pending, c)
+		}
+	}
+
+	d.Batches = commitBatches(pending, s.cfg.TokenBudget/2)
+	counts := term.Dim(fmt.Sprintf("(%d cached, %d in %d batches)", len(d.Summaries), len(pending), len(d.Batches)))
+
+	switch {
+	case over:
+		fmt.Fprintf(stdout, "Prompt for %s is ~%d tokens, over the budget of %d: summarizing its commits first %s\n",
+			term.Cyan(d.Tag), tokens, s.cfg.TokenBudget, counts)
+	case len(pending) > 0:
+		fmt.Fprintf(stdout, "Summarizing the commits of %s for the cache %s\n", term.Cyan(d.Tag), counts)
+	default:
+		logVerbose(s.cfg, "Using the cached summaries of all %d commits", len(d.Commits))
+	}
+}
+
+// summarize is the map step for a draft whose prompt is built from summaries: the
+// commits not found in the cache are summarized in batches, in parallel, then the prompt
+// is rebuilt from the summaries for the final pass. It does nothing for other drafts.
+func (s *session) summarize(ctx context.Context, d *draft) error {
+	if d.Summaries == nil {
+		return nil
+	}
+
+	if err := s.summarizeBatches(ctx, d); err != nil {
+		return err
+	}
+
+	summaries := make([]prompt.Summary, 0, len(d.Commits))
+
+	for _, c := range d.Commits {
+		text, ok := d.Summaries[c.Hash]
+		if !ok {
+			// Left out by the model; the subject is better than nothing
+			text = c.Subject()
+		}
+
+		summaries = append(summaries, prompt.Summary{Hash: c.Hash, Text: text})
+	}
+
+	d.PromptData.Summaries = summaries
+	d.Prompt = prompt.Generate(d.PromptData)
+	d.Summaries, d.Batches = nil, nil
+
+	if tokens := prompt.EstimateTokens(d.Prompt); tokens > s.cfg.TokenBudget {
+		fmt.Fprintln(stdout, term.Yellow(fmt.Sprintf("Warning: the summaries of %s are still ~%d tokens, over the budget of %d",
+			d.Tag, tokens, s.cfg.TokenBudget)))
+	}
+
+	if err := os.WriteFile(d.PromptPath, []byte(d.Prompt), 0o644); err != nil {
+		return errors.Runtime("failed to write prompt file", err)
+	}
+
+	return nil
+}
+
+// summarizeBatches asks the model for the summaries of d.Batches, at most summaryJobs
+// at a time, adding them to d.Summaries and to the cache as each batch finishes.
+func (s *session) summarizeBatches(ctx context.Context, d *draft) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		done     int
+		firstErr error
+	)
+
+	sem := make(chan struct{}, summaryJobs)
+
+	for i, batch := range d.Batches {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			summaries, model, err := s.summarizeBatch(ctx, d, i, batch)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+
+					cancel()
+				}
+
+				return
+			}
+
+			maps.Copy(d.Summaries, summaries)
+			s.cacheSummaries(summaries, model)
+
+			done++
+
+			fmt.Fprintf(stdout, "Summarized batch %d/%d of %s %s\n",
+				done, len(d.Batches), term.Cyan(d.Tag), term.Dim(fmt.Sprintf("(%d commits)", len(batch))))
+		}()
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+// summarizeBatch asks the model for the summaries of batch i of the commits of d, within
+// the generate timeout, and returns them by full commit hash with the label of the model
+// that wrote them.
+func (s *session) summarizeBatch(
+	ctx context.Context, d *draft, i int, batch []git.Commit,
+) (map[string]string, string, error) {
+	p := prompt.Summarize(prompt.BatchData{
+		TargetTag:     d.Tag,
+		Unreleased:    d.Unreleased,
+		Component:     d.Component,
+		Batch:         i + 1,
+		Batches:       len(d.Batches),
+		CommitDetails: git.FormatCommits(batch),
+		Diffs:         d.PromptData.Diffs,
+	})
+
+	var output, model string
+
+	err := runPhase(ctx, s.cfg, phaseGenerate, func(ctx context.Context) error {
+		var err error
+
+		output, model, err = llm.GenerateLabeled(ctx, s.generator, p)
+
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The model abbreviates hashes; match them to the commits of the batch
+	summaries := map[string]string{}
+
+	for short, text := range prompt.ParseSummaries(output) {
+		for _, c := range batch {
+			if strings.HasPrefix(c.Hash, short) {
+				summaries[c.Hash] = text
+			}
+		}
+	}
+
+	return summaries, model, nil
+}
+
+// cacheKey identifies the summary of a commit by the model that wrote it, the summary
+// prompt and the diffs embedded in it.
+func (s *session) cacheKey(hash, model string) cache.Key {
+	return cache.Key{
+		Commit:        hash,
+		Model:         model,
+		PromptVersion: prompt.SummaryVersion,
+		Diffs:         git.DiffVariant(s.cfg.Diffs),
+	}
+}
+
+// cachedSummaries returns the summaries of the commits found in the cache, by hash. The
+// summaries of any model of the chain will do, those of earlier models first.
+func (s *session) cachedSummaries(commits []git.Commit) map[string]string {
+	summaries := map[string]string{}
+	if s.cache == nil {
+		return summaries
+	}
+
+	for _, c := range commits {
+		for _, model := range llm.Models(s.cfg.Model) {
+			if text, ok := s.cache.Get(s.cacheKey(c.Hash, llm.Label(s.cfg.Backend, model))); ok {
+				summaries[c.Hash] = text
+
+				break
+			}
+		}
+	}
+
+	return summaries
+}
+
+// cacheSummaries stores new summaries written by model. A failure only costs a later run
+// the same summaries again, so it is not an error.
+func (s *session) cacheSummaries(summaries map[string]string, model string) {
+	if s.cache == nil {
+		return
+	}
+
+	for hash, text := range summaries {
+		if err := s.cache.Put(s.cacheKey(hash, model), text); err != nil {
+			logVerbose(s.cfg, "Could not cache summary of %s: %v", hash, err)
+		}
+	}
+}
+
+// openCache returns the summary cache, or nil when it is disabled (--no-cache) or its
+// directory cannot be determined.
+func openCache(cfg *Config) *cache.Store {
+	if cfg.NoCache {
+		return nil
+	}
+
+	store, err := cache.Open()
+	if err != nil {
+		logVerbose(cfg, "Summary cache disabled: %v", err)
+
+		return nil
+	}
+
+	return store
+}
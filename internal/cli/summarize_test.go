@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/herald/internal/cache"
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/git"
+	"github.com/AndreyAkinshin/herald/internal/prompt"
+)
+
+// sizedCommit returns a commit whose message is about tokens tokens long.
+func sizedCommit(hash string, tokens int) git.Commit {
+	return git.Commit{Hash: hash, Message: strings.Repeat("word", tokens)}
+}
+
+func TestCommitBatches(t *testing.T) {
+	commits := []git.Commit{
+		sizedCommit("a", 400), sizedCommit("b", 400), sizedCommit("c", 400), sizedCommit("d", 100),
+	}
+
+	batches := commitBatches(commits, 1000)
+
+	var sizes []int
+	for _, b := range batches {
+		sizes = append(sizes, len(b))
+	}
+
+	if len(sizes) != 2 || sizes[0] != 2 || sizes[1] != 2 {
+		t.Errorf("got batch sizes %v, want [2 2]", sizes)
+	}
+
+	if batches[1][0].Hash != "c" {
+		t.Errorf("commits out of order: %q", batches[1][0].Hash)
+	}
+}
+
+func TestCommitBatches_trims_huge_commit(t *testing.T) {
+	c := sizedCommit("a", 10)
+	c.Stat = strings.Repeat(" vendor/lib/file.go | 100 ++++\n", 10_000)
+
+	batches := commitBatches([]git.Commit{c, sizedCommit("b", 10)}, 1000)
+
+	if len(batches) != 2 {
+		t.Fatalf("got %d batches, want 2", len(batches))
+	}
+
+	trimmed := batches[0][0]
+	if tokens := prompt.EstimateTokens(git.FormatCommits([]git.Commit{trimmed})); tokens > 1000 {
+		t.Errorf("trimmed commit is %d tokens, want at most 1000", tokens)
+	}
+
+	if !strings.Contains(trimmed.Stat, "more lines") {
+		t.Error("missing note about the cut file list")
+	}
+}
+
+func TestCommitBatches_drops_diff_of_huge_commit(t *testing.T) {
+	c := sizedCommit("a", 10)
+	c.Diff = strings.Repeat("+line\n", 10_000)
+
+	batches := commitBatches([]git.Commit{c}, 1000)
+
+	if got := batches[0][0]; got.Diff != "" || got.Stat != c.Stat {
+		t.Errorf("got diff of %d bytes and stat %q, want the diff dropped only", len(got.Diff), got.Stat)
+	}
+}
+
+func TestPlanSummaries(t *testing.T) {
+	commits := []git.Commit{{Hash: "aaaaaaa111", Message: "feat: export"}, {Hash: "bbbbbbb222", Message: "fix: login"}}
+	store := cache.New(t.TempDir())
+
+	tests := []struct {
+		name    string
+		cache   *cache.Store
+		budget  int
+		tokens  int
+		want    bool
+		pending int
+	}{
+		{"small without cache", nil, defaultTokenBudget, 1000, false, 0},
+		{"small with cache", store, defaultTokenBudget, 1000, true, 1},
+		{"over the budget without cache", nil, 500, 1000, true, 2},
+		{"never summarizing", store, 0, 1000, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &session{cfg: &Config{}, generator: &fakeGenerator{}, cache: tt.cache}
+			s.cfg.Backend, s.cfg.TokenBudget = "fake", tt.budget
+
+			if tt.cache != nil {
+				if err := tt.cache.Put(s.cacheKey("aaaaaaa111", "fake"), "Added export"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			d := &draft{Tag: "v2.0", Commits: commits}
+			s.planSummaries(d, tt.tokens)
+
+			if got := d.Summaries != nil; got != tt.want {
+				t.Fatalf("built from summaries = %v, want %v", got, tt.want)
+			}
+
+			pending := 0
+			for _, batch := range d.Batches {
+				pending += len(batch)
+			}
+
+			if pending != tt.pending {
+				t.Errorf("%d commits to summarize, want %d", pending, tt.pending)
+			}
+		})
+	}
+}
+
+func TestSummarize_rebuilds_prompt_from_summaries(t *testing.T) {
+	g := &fakeGenerator{notes: "Summaries:\n- aaaaaaa: Added export (#12)\n- bbbbbbb: internal: bump deps\n"}
+	s := &session{cfg: &Config{}, generator: g, cache: cache.New(t.TempDir())}
+	s.cfg.TokenBudget = defaultTokenBudget
+
+	commits := []git.Commit{
+		{Hash: "aaaaaaa111", Message: "feat: export"},
+		{Hash: "bbbbbbb222", Message: "chore: deps"},
+		{Hash: "ccccccc333", Message: "fix: login"},
+	}
+
+	d := &draft{
+		Tag:        "v2.0",
+		PromptPath: filepath.Join(t.TempDir(), "prompt.md"),
+		PromptData: prompt.Data{TargetTag: "v2.0", CommitDetails: "all commits"},
+		Commits:    commits,
+		Summaries:  map[string]string{},
+		Batches:    [][]git.Commit{commits[:2], commits[2:]},
+	}
+
+	if err := s.summarize(t.Context(), d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(g.prompts) != 2 || !strings.Contains(g.prompts[0], "2 batches") {
+		t.Errorf("got %d summary prompts, want 2", len(g.prompts))
+	}
+
+	want := "- aaaaaaa Added export (#12)\n- bbbbbbb internal: bump deps\n- ccccccc fix: login"
+	if !strings.Contains(d.Prompt, want) || strings.Contains(d.Prompt, "all commits") {
+		t.Errorf("prompt not rebuilt from the summaries:\n%s", d.Prompt)
+	}
+
+	if d.Summaries != nil || d.Batches != nil {
+		t.Error("summaries and batches should be cleared once the prompt is rebuilt")
+	}
+
+	// Summaries are cached under the model that wrote them; the subject stand-in is not cached
+	if text, ok := s.cache.Get(s.cacheKey("aaaaaaa111", "fake")); !ok || text != "Added export (#12)" {
+		t.Errorf("got cached summary %q, %v", text, ok)
+	}
+
+	if _, ok := s.cache.Get(s.cacheKey("ccccccc333", "fake")); ok {
+		t.Error("subject stand-in was cached")
+	}
+}
+
+func TestCachedSummaries_per_diff_mode(t *testing.T) {
+	s := &session{cfg: &Config{}, cache: cache.New(t.TempDir())}
+	s.cfg.Backend = "claude"
+
+	if err := s.cache.Put(s.cacheKey("aaaaaaa111", "claude"), "from the message"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.cfg.Diffs = git.DiffsSmall
+	commits := []git.Commit{{Hash: "aaaaaaa111"}}
+
+	if got := s.cachedSummaries(commits); len(got) != 0 {
+		t.Errorf("got %v, want no summaries written without diffs", got)
+	}
+
+	s.cfg.Diffs = git.DiffsNone
+	if got := s.cachedSummaries(commits); got["aaaaaaa111"] != "from the message" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestCachedSummaries_any_model_of_the_chain(t *testing.T) {
+	s := &session{cfg: &Config{}, cache: cache.New(t.TempDir())}
+	s.cfg.Backend, s.cfg.Model = "anthropic", "opus,sonnet"
+
+	for hash, model := range map[string]string{
+		"aaaaaaa111": "anthropic/sonnet",
+		"bbbbbbb222": "anthropic/haiku",
+		"ccccccc333": "claude/opus",
+	} {
+		if err := s.cache.Put(s.cacheKey(hash, model), "by "+model); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := s.cachedSummaries([]git.Commit{{Hash: "aaaaaaa111"}, {Hash: "bbbbbbb222"}, {Hash: "ccccccc333"}})
+
+	if len(got) != 1 || got["aaaaaaa111"] != "by anthropic/sonnet" {
+		t.Errorf("got %v, want only the summary of the fallback model", got)
+	}
+}
+
+func TestSummarize_all_cached(t *testing.T) {
+	g := &fakeGenerator{}
+	s := &session{cfg: &Config{}, generator: g}
+
+	d := &draft{
+		Tag:        "v2.0",
+		PromptPath: filepath.Join(t.TempDir(), "prompt.md"),
+		Commits:    []git.Commit{{Hash: "aaaaaaa111", Message: "feat: export"}},
+		Summaries:  map[string]string{"aaaaaaa111": "Added export"},
+	}
+
+	if err := s.summarize(t.Context(), d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(g.prompts) != 0 || !strings.Contains(d.Prompt, "- aaaaaaa Added export") {
+		t.Errorf("got %d prompts and prompt:\n%s", len(g.prompts), d.Prompt)
+	}
+}
+
+func TestSummarize_error(t *testing.T) {
+	s := &session{cfg: &Config{}, generator: &fakeGenerator{err: errors.Runtime("overloaded", nil)}}
+
+	d := &draft{
+		Tag:       "v2.0",
+		Summaries: map[string]string{},
+		Batches:   [][]git.Commit{{sizedCommit("a", 1)}, {sizedCommit("b", 1)}},
+	}
+
+	if err := s.summarize(t.Context(), d); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/forge"
+	"github.com/AndreyAkinshin/herald/internal/git"
+	"github.com/AndreyAkinshin/herald/internal/term"
+)
+
+func parseUnreleasedArgs(cfg *Config, args []string) (*Config, error) {
+	fs := newFlagSet(cfg, "herald unreleased", printUnreleasedUsage)
+	fs.StringVar(&cfg.Output, "output", "", "")
+	fs.StringVar(&cfg.Output, "o", "", "")
+	fs.StringVar(&cfg.Ref, "ref", "HEAD", "")
+	fs.StringVar(&cfg.Since, "since", "", "")
+
+	if err := fs.Parse(reorderArgs(args)); err != nil {
+		return nil, errors.Config(err.Error())
+	}
+
+	if fs.NArg() > 1 {
+		return nil, errors.Config("unexpected argument: " + fs.Arg(1))
+	}
+
+	cfg.Instructions = fs.Arg(0)
+	cfg.flagSettings = flagSettings(fs, cfg)
+
+	return cfg, nil
+}
+
+func printUnreleasedUsage() {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s — Draft notes for changes since the latest tag\n", term.BoldCyan("herald unreleased"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("USAGE"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald unreleased"), term.Yellow("[\"instructions\"]"), term.Dim("[options]"))
+	fmt.Fprintf(&b, "    %s %s %s\n",
+		term.BoldCyan("herald HEAD"), term.Yellow("[\"instructions\"]"), term.Dim("[options]"))
+	b.WriteString("\n")
+	b.WriteString("    Generates notes for the commits between the latest tag reachable from the ref\n")
+	b.WriteString("    and the ref itself. Only git and the model are used: no release is read or updated.\n")
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", term.BoldYellow("OPTIONS"))
+	fmt.Fprintf(&b, "        %s %s         Branch, commit or other ref %s\n",
+		term.Green("--ref"), term.Yellow("<ref>"), term.Dim("(default: HEAD)"))
+	fmt.Fprintf(&b, "        %s %s       Start of the commit range %s\n",
+		term.Green("--since"), term.Yellow("<ref>"), term.Dim("(default: latest tag)"))
+	fmt.Fprintf(&b, "        %s %s  Monorepo component: start from its latest tag\n",
+		term.Green("--component"), term.Yellow("<name>"))
+	fmt.Fprintf(&b, "                            %s\n", term.Dim("and only include commits touching its paths"))
+	fmt.Fprintf(&b, "    %s %s %s     Save notes to file\n",
+		term.Green("-o,"), term.Green("--output"), term.Yellow("<file>"))
+	fmt.Fprintf(&b, "                            %s\n",
+		term.Dim("(default: <tmpdir>/herald/<repo>-unreleased.md)"))
+	b.WriteString("\n")
+	b.WriteString("    Model, backend, section and footer options are the same as for a release.\n")
+	b.WriteString("\n")
+
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// runUnreleased drafts notes for the commits after the latest tag without accessing the forge.
+func runUnreleased(ctx context.Context, cfg *Config) error {
+	root, err := git.FindRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	generator, err := newGenerator(cfg)
+	if err != nil {
+		return err
+	}
+
+	filter, err := commitFilter(&cfg.Settings)
+	if err != nil {
+		return err
+	}
+
+	if err := checkComponent(cfg); err != nil {
+		return err
+	}
+
+	var latest string
+
+	err = runPhase(ctx, cfg, phaseFetch, func(ctx context.Context) error {
+		var err error
+
+		if generator, err = checkGenerator(ctx, cfg, generator); err != nil {
+			return err
+		}
+
+		latest, err = latestTag(ctx, cfg)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if latest != "" {
+		fmt.Fprintf(stdout, "Changes on %s since %s\n", term.Cyan(cfg.Ref), term.Cyan(latest))
+	} else {
+		fmt.Fprintf(stdout, "No tags found, using the full history of %s\n", term.Cyan(cfg.Ref))
+	}
+
+	s := &session{
+		cfg:       cfg,
+		generator: generator,
+		repoInfo:  &forge.RepoInfo{Name: filepath.Base(root)},
+		filter:    filter,
+		cache:     openCache(cfg),
+		stream:    true,
+	}
+
+	d := &draft{
+		Tag:        cfg.Ref,
+		PrevTag:    latest,
+		Output:     s.outputPath(unreleasedName(cfg.Component, cfg.Ref)),
+		Unreleased: true,
+		Component:  cfg.Component,
+	}
+
+	err = runPhase(ctx, cfg, phaseFetch, func(ctx context.Context) error { return s.writePrompt(ctx, d) })
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Prompt saved to %s\n", term.Cyan(d.PromptPath))
+	fmt.Fprintf(stdout, "Generating draft notes with %s...\n", s.generatorName())
+
+	if err := s.generate(ctx, d); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Draft notes saved to %s\n", term.Cyan(d.Output))
+	printPreview(d)
+
+	return nil
+}
+
+// latestTag returns the start of the preview: the --since ref, or else the latest tag
+// reachable from the ref (of the component, if one is selected).
+func latestTag(ctx context.Context, cfg *Config) (string, error) {
+	// Tags are fetched for an accurate starting point, but the preview works offline too
+	logVerbose(cfg, "Fetching tags...")
+
+	if err := git.FetchTags(ctx); err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+
+		logVerbose(cfg, "Could not fetch tags: %v", err)
+	}
+
+	if cfg.Since != "" {
+		return cfg.Since, checkRef(ctx, "--since", cfg.Since)
+	}
+
+	var match string
+	if cfg.Component != "" {
+		match = cfg.Components[cfg.Component].TagPrefix + "*"
+	}
+
+	return git.LatestTag(ctx, cfg.Ref, match)
+}
+
+// unreleasedName is used in place of the tag in output file names, e.g. "unreleased"
+// for HEAD, "unreleased-feature-x" for the branch feature/x or "api-unreleased" for
+// the api component.
+func unreleasedName(component, ref string) string {
+	name := "unreleased"
+	if ref != "HEAD" {
+		name += "-" + safeName(ref)
+	}
+
+	if component != "" {
+		name = component + "-" + name
+	}
+
+	return name
+}
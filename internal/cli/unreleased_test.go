@@ -0,0 +1,45 @@
+package cli
+
+import "testing"
+
+func TestParseArgs_unreleased(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"unreleased", "--ref", "main", "--since", "v1.0", "Keep it short"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Command != commandUnreleased || cfg.Ref != "main" || cfg.Since != "v1.0" ||
+		cfg.Instructions != "Keep it short" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestParseArgs_head_shorthand(t *testing.T) {
+	cfg, err := ParseArgs("1.0.0", []string{"HEAD"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Command != commandUnreleased || cfg.Ref != "HEAD" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestUnreleasedName(t *testing.T) {
+	tests := []struct {
+		component string
+		ref       string
+		want      string
+	}{
+		{"", "HEAD", "unreleased"},
+		{"", "main", "unreleased-main"},
+		{"", "feature/x", "unreleased-feature-x"},
+		{"api", "HEAD", "api-unreleased"},
+	}
+
+	for _, tt := range tests {
+		if got := unreleasedName(tt.component, tt.ref); got != tt.want {
+			t.Errorf("unreleasedName(%q, %q) = %q, want %q", tt.component, tt.ref, got, tt.want)
+		}
+	}
+}
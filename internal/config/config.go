@@ -0,0 +1,543 @@
+// Package config loads herald settings from config files and the environment
+// and merges them with command-line flags.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// Setting keys, as written in config files. The environment variable for a key
+// is HERALD_ followed by the upper-cased key (e.g. HERALD_CHANGELOG_HEADING).
+const (
+	KeyModel            = "model"
+	KeyBackend          = "backend"
+	KeyForge            = "forge"
+	KeyTagPattern       = "tag_pattern"
+	KeyPrevious         = "previous"
+	KeyPrereleases      = "prereleases"
+	KeyInstructions     = "instructions"
+	KeySections         = "sections"
+	KeyExclude          = "exclude"
+	KeyExcludeAuthors   = "exclude_authors"
+	KeyExcludePaths     = "exclude_paths"
+	KeyNoMerges         = "no_merges"
+	KeyFirstParent      = "first_parent"
+	KeyOutput           = "output"
+	KeyChangelog        = "changelog"
+	KeyChangelogHeading = "changelog_heading"
+	KeyChangelogLink    = "changelog_link"
+	KeyFooter           = "footer"
+	KeyNoFooter         = "no_footer"
+	KeyNoFallback       = "no_fallback"
+	KeyTimeout          = "timeout"
+	KeyTokenBudget      = "token_budget"
+	KeyNoCache          = "no_cache"
+	KeyDiffs            = "diffs"
+	KeyComponents       = "components"
+)
+
+// Keys lists all setting keys in display order.
+var Keys = []string{
+	KeyModel, KeyBackend, KeyForge, KeyTagPattern, KeyPrevious, KeyPrereleases,
+	KeyInstructions, KeySections, KeyExclude, KeyExcludeAuthors, KeyExcludePaths, KeyNoMerges, KeyFirstParent,
+	KeyOutput,
+	KeyChangelog, KeyChangelogHeading, KeyChangelogLink, KeyFooter, KeyNoFooter, KeyNoFallback, KeyTimeout,
+	KeyTokenBudget, KeyNoCache, KeyDiffs, KeyComponents,
+}
+
+// Layer sources that are not files.
+const (
+	SourceDefault = "default"
+	SourceEnv     = "env"
+	SourceFlags   = "command line"
+)
+
+// repoFiles and userFiles are the config file names looked up in the repository
+// root and in $XDG_CONFIG_HOME/herald.
+var (
+	repoFiles = []string{".herald.toml", ".herald.yaml", ".herald.yml"}
+	userFiles = []string{"config.toml", "config.yaml", "config.yml"}
+)
+
+// Settings holds the merged configuration.
+type Settings struct {
+	Model   string
+	Backend string
+	Forge   string
+	// TagPattern is a glob; only releases whose tags match it are considered.
+	TagPattern string
+	// Previous is the strategy for choosing the previous release; empty selects the
+	// default of the forge.
+	Previous string
+	// Prereleases is the policy for choosing prereleases as the previous release.
+	Prereleases  string
+	Instructions string
+	// Sections overrides the section headings the model groups changes by.
+	Sections []string
+	// Exclude holds regular expressions; commits whose subject matches one are left out.
+	Exclude []string
+	// ExcludeAuthors holds regular expressions matched against "Name <email>" of the author.
+	ExcludeAuthors []string
+	// ExcludePaths are git pathspec globs; commits that only change matching files are left out.
+	ExcludePaths []string
+	// NoMerges leaves out merge commits.
+	NoMerges bool
+	// FirstParent follows only the first parent of merges, so a merged branch appears
+	// as its merge commit.
+	FirstParent bool
+	// Output is the notes file; {repo} and {tag} are replaced with the repository name and tag.
+	Output           string
+	Changelog        string
+	ChangelogHeading string
+	ChangelogLink    string
+	// Footer replaces the attribution footer text; {version} is the herald version.
+	Footer   string
+	NoFooter bool
+	// NoFallback makes a failing model an error instead of rendering notes from a template.
+	NoFallback bool
+	// Timeout limits the phases of a run: "generate=20m" sets the timeout of one phase,
+	// a bare duration that of every phase.
+	Timeout []string
+	// TokenBudget is the estimated size in tokens above which a prompt is split: the
+	// commits are summarized in batches first. Zero never splits.
+	TokenBudget int
+	// NoCache neither reads nor writes the commit summary cache.
+	NoCache bool
+	// Diffs is how much of the patches of the commits goes into the prompt: none, small or all.
+	Diffs string
+	// Components maps monorepo component names to their tags and paths.
+	Components map[string]Component
+
+	// Sources maps each key that has a value to the source it came from.
+	Sources map[string]string
+}
+
+// Component is a part of a monorepo released on its own, with tags such as "api/v1.2.0".
+type Component struct {
+	// TagPrefix starts every release tag of the component, e.g. "api/".
+	TagPrefix string
+	// Paths are git pathspec globs (e.g. "services/api/**"); only commits touching them are included.
+	Paths []string
+	// Changelog overrides the changelog file for the component.
+	Changelog string
+}
+
+// Layer is one source of settings, such as a config file or the environment.
+type Layer struct {
+	Source string
+	Values map[string]any
+}
+
+// Merge applies layers in order of increasing precedence.
+func Merge(layers ...Layer) (*Settings, error) {
+	s := &Settings{Sources: map[string]string{}}
+
+	for _, l := range layers {
+		if err := s.apply(l); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Get returns the value of the setting for key.
+func (s *Settings) Get(key string) any {
+	switch key {
+	case KeyModel:
+		return s.Model
+	case KeyBackend:
+		return s.Backend
+	case KeyForge:
+		return s.Forge
+	case KeyTagPattern:
+		return s.TagPattern
+	case KeyPrevious:
+		return s.Previous
+	case KeyPrereleases:
+		return s.Prereleases
+	case KeyInstructions:
+		return s.Instructions
+	case KeySections:
+		return s.Sections
+	case KeyExclude:
+		return s.Exclude
+	case KeyExcludeAuthors:
+		return s.ExcludeAuthors
+	case KeyExcludePaths:
+		return s.ExcludePaths
+	case KeyNoMerges:
+		return s.NoMerges
+	case KeyFirstParent:
+		return s.FirstParent
+	case KeyOutput:
+		return s.Output
+	case KeyChangelog:
+		return s.Changelog
+	case KeyChangelogHeading:
+		return s.ChangelogHeading
+	case KeyChangelogLink:
+		return s.ChangelogLink
+	case KeyFooter:
+		return s.Footer
+	case KeyNoFooter:
+		return s.NoFooter
+	case KeyNoFallback:
+		return s.NoFallback
+	case KeyTimeout:
+		return s.Timeout
+	case KeyTokenBudget:
+		return s.TokenBudget
+	case KeyNoCache:
+		return s.NoCache
+	case KeyDiffs:
+		return s.Diffs
+	case KeyComponents:
+		return s.Components
+	default:
+		return nil
+	}
+}
+
+// apply sets every value of the layer, checking keys and types.
+func (s *Settings) apply(l Layer) error {
+	for key := range l.Values {
+		if !slices.Contains(Keys, key) {
+			return errors.Config(fmt.Sprintf("%s: unknown setting %q", l.Source, key))
+		}
+	}
+
+	for _, key := range Keys {
+		v, ok := l.Values[key]
+		if !ok {
+			continue
+		}
+
+		if err := s.set(key, v); err != nil {
+			return errors.Config(fmt.Sprintf("%s: %v", l.Source, err))
+		}
+
+		s.Sources[key] = l.Source
+	}
+
+	return nil
+}
+
+func (s *Settings) set(key string, v any) error {
+	var err error
+
+	switch key {
+	case KeyModel:
+		s.Model, err = asString(key, v)
+	case KeyBackend:
+		s.Backend, err = asString(key, v)
+	case KeyForge:
+		s.Forge, err = asString(key, v)
+	case KeyTagPattern:
+		s.TagPattern, err = asString(key, v)
+	case KeyPrevious:
+		s.Previous, err = asString(key, v)
+	case KeyPrereleases:
+		s.Prereleases, err = asString(key, v)
+	case KeyInstructions:
+		s.Instructions, err = asString(key, v)
+	case KeySections:
+		s.Sections, err = asList(key, v)
+	case KeyExclude:
+		s.Exclude, err = asList(key, v)
+	case KeyExcludeAuthors:
+		s.ExcludeAuthors, err = asList(key, v)
+	case KeyExcludePaths:
+		s.ExcludePaths, err = asList(key, v)
+	case KeyNoMerges:
+		s.NoMerges, err = asBool(key, v)
+	case KeyFirstParent:
+		s.FirstParent, err = asBool(key, v)
+	case KeyOutput:
+		s.Output, err = asString(key, v)
+	case KeyChangelog:
+		s.Changelog, err = asString(key, v)
+	case KeyChangelogHeading:
+		s.ChangelogHeading, err = asString(key, v)
+	case KeyChangelogLink:
+		s.ChangelogLink, err = asString(key, v)
+	case KeyFooter:
+		s.Footer, err = asString(key, v)
+	case KeyNoFooter:
+		s.NoFooter, err = asBool(key, v)
+	case KeyNoFallback:
+		s.NoFallback, err = asBool(key, v)
+	case KeyTimeout:
+		s.Timeout, err = asList(key, v)
+	case KeyTokenBudget:
+		s.TokenBudget, err = asCount(key, v)
+	case KeyNoCache:
+		s.NoCache, err = asBool(key, v)
+	case KeyDiffs:
+		s.Diffs, err = asString(key, v)
+	case KeyComponents:
+		s.Components, err = asComponents(key, v)
+	}
+
+	return err
+}
+
+// EnvLayer reads HERALD_* environment variables. Lists are comma-separated.
+// Components cannot be set from the environment.
+func EnvLayer() Layer {
+	values := map[string]any{}
+
+	for _, key := range Keys {
+		if key == KeyComponents {
+			continue
+		}
+
+		if v, ok := os.LookupEnv(EnvName(key)); ok {
+			values[key] = v
+		}
+	}
+
+	return Layer{Source: SourceEnv, Values: values}
+}
+
+// EnvName returns the environment variable for a setting key.
+func EnvName(key string) string {
+	return "HERALD_" + strings.ToUpper(key)
+}
+
+// LoadRepo loads .herald.toml or .herald.yaml from the repository root.
+// Returns an empty layer if there is no config file.
+func LoadRepo(root string) (Layer, error) {
+	return loadFirst(root, repoFiles)
+}
+
+// LoadUser loads config.toml or config.yaml from $XDG_CONFIG_HOME/herald
+// (~/.config/herald if unset). Returns an empty layer if there is no config file.
+func LoadUser() (Layer, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Layer{}, nil
+		}
+
+		dir = filepath.Join(home, ".config")
+	}
+
+	return loadFirst(filepath.Join(dir, "herald"), userFiles)
+}
+
+// loadFirst loads the only existing file among names in dir; several matches are an error.
+func loadFirst(dir string, names []string) (Layer, error) {
+	var found []string
+
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			found = append(found, filepath.Join(dir, name))
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Layer{}, nil
+	case 1:
+		return LoadFile(found[0])
+	default:
+		return Layer{}, errors.Config("several config files found, keep one: " + strings.Join(found, ", "))
+	}
+}
+
+// LoadFile loads a TOML or YAML config file, chosen by extension.
+func LoadFile(path string) (Layer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Layer{}, errors.Runtime("failed to read config file", err)
+	}
+
+	values := map[string]any{}
+
+	if strings.HasSuffix(path, ".toml") {
+		err = toml.Unmarshal(data, &values)
+	} else if len(bytes.TrimSpace(data)) > 0 {
+		err = yaml.Unmarshal(data, &values)
+	}
+
+	if err != nil {
+		return Layer{}, errors.Config(fmt.Sprintf("invalid config file %s: %v", path, err))
+	}
+
+	return Layer{Source: path, Values: values}, nil
+}
+
+// Format renders a setting value for display.
+func Format(v any) string {
+	switch v := v.(type) {
+	case []string:
+		return strings.Join(v, ", ")
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]Component:
+		names := slices.Sorted(maps.Keys(v))
+		for i, name := range names {
+			names[i] = name + " (" + v[name].TagPrefix + "*)"
+		}
+
+		return strings.Join(names, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func asString(key string, v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", key)
+	}
+
+	return s, nil
+}
+
+// asList accepts a list of strings or, as in environment variables, a comma-separated string.
+func asList(key string, v any) ([]string, error) {
+	switch v := v.(type) {
+	case []string:
+		return v, nil
+	case string:
+		var items []string
+
+		for item := range strings.SplitSeq(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		return items, nil
+	case []any:
+		items := make([]string, 0, len(v))
+
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings", key)
+			}
+
+			items = append(items, s)
+		}
+
+		return items, nil
+	default:
+		return nil, fmt.Errorf("%s must be a list of strings", key)
+	}
+}
+
+// asBool accepts a boolean or, as in environment variables, a string such as "true" or "1".
+func asBool(key string, v any) (bool, error) {
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("%s must be true or false", key)
+		}
+
+		return b, nil
+	default:
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+}
+
+// asCount accepts a non-negative integer or, as in environment variables, a string holding one.
+func asCount(key string, v any) (int, error) {
+	var n int
+
+	switch v := v.(type) {
+	case int:
+		n = v
+	case int64:
+		n = int(v)
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a whole number", key)
+		}
+
+		n = i
+	default:
+		return 0, fmt.Errorf("%s must be a whole number", key)
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("%s must not be negative", key)
+	}
+
+	return n, nil
+}
+
+// componentFields are the keys of a component table.
+var componentFields = []string{"tag_prefix", "paths", "changelog"}
+
+// asComponents accepts a table of component tables, each with a tag_prefix and optional
+// paths and changelog.
+func asComponents(key string, v any) (map[string]Component, error) {
+	tables, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a table of components", key)
+	}
+
+	components := make(map[string]Component, len(tables))
+
+	for name, t := range tables {
+		fields, ok := t.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s must be a table", key, name)
+		}
+
+		for field := range fields {
+			if !slices.Contains(componentFields, field) {
+				return nil, fmt.Errorf("%s.%s: unknown field %q", key, name, field)
+			}
+		}
+
+		var (
+			c   Component
+			err error
+		)
+
+		if c.TagPrefix, err = asString(key+"."+name+".tag_prefix", fields["tag_prefix"]); err != nil {
+			return nil, err
+		}
+
+		if c.TagPrefix == "" {
+			return nil, fmt.Errorf("%s.%s.tag_prefix must not be empty", key, name)
+		}
+
+		if p, ok := fields["paths"]; ok {
+			if c.Paths, err = asList(key+"."+name+".paths", p); err != nil {
+				return nil, err
+			}
+		}
+
+		if cl, ok := fields["changelog"]; ok {
+			if c.Changelog, err = asString(key+"."+name+".changelog", cl); err != nil {
+				return nil, err
+			}
+		}
+
+		components[name] = c
+	}
+
+	return components, nil
+}
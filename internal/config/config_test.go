@@ -0,0 +1,233 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadRepo_toml(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".herald.toml"), `
+model = "opus"
+sections = ["Added", "Fixed"]
+no_footer = true
+`)
+
+	l, err := LoadRepo(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := Merge(l)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Model != "opus" || !slices.Equal(s.Sections, []string{"Added", "Fixed"}) || !s.NoFooter {
+		t.Errorf("got %+v", s)
+	}
+
+	if s.Sources[KeyModel] != filepath.Join(dir, ".herald.toml") {
+		t.Errorf("source = %q", s.Sources[KeyModel])
+	}
+}
+
+func TestLoadRepo_yaml(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".herald.yaml"), "exclude:\n  - '^chore'\nfooter: Made with {version}\n")
+
+	l, err := LoadRepo(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := Merge(l)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(s.Exclude, []string{"^chore"}) || s.Footer != "Made with {version}" {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestLoadRepo_missing(t *testing.T) {
+	l, err := LoadRepo(t.TempDir())
+	if err != nil || len(l.Values) != 0 {
+		t.Errorf("got %+v, %v", l, err)
+	}
+}
+
+func TestLoadRepo_ambiguous(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".herald.toml"), "")
+	writeFile(t, filepath.Join(dir, ".herald.yaml"), "")
+
+	if _, err := LoadRepo(dir); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestLoadUser_xdg(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeFile(t, filepath.Join(dir, "herald", "config.toml"), `backend = "ollama"`)
+
+	l, err := LoadUser()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if l.Values[KeyBackend] != "ollama" {
+		t.Errorf("got %+v", l)
+	}
+}
+
+func TestMerge_precedence(t *testing.T) {
+	user := Layer{Source: "user", Values: map[string]any{KeyModel: "haiku", KeyForge: "gitlab"}}
+	repo := Layer{Source: "repo", Values: map[string]any{KeyModel: "sonnet"}}
+	flags := Layer{Source: SourceFlags, Values: map[string]any{KeyModel: "opus"}}
+
+	s, err := Merge(user, repo, flags)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Model != "opus" || s.Sources[KeyModel] != SourceFlags {
+		t.Errorf("model = %q from %q", s.Model, s.Sources[KeyModel])
+	}
+
+	if s.Forge != "gitlab" || s.Sources[KeyForge] != "user" {
+		t.Errorf("forge = %q from %q", s.Forge, s.Sources[KeyForge])
+	}
+}
+
+func TestEnvLayer(t *testing.T) {
+	t.Setenv("HERALD_SECTIONS", "Added, Fixed")
+	t.Setenv("HERALD_NO_FOOTER", "1")
+
+	s, err := Merge(EnvLayer())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(s.Sections, []string{"Added", "Fixed"}) || !s.NoFooter {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestMerge_unknown_key(t *testing.T) {
+	if _, err := Merge(Layer{Source: "x", Values: map[string]any{"modle": "opus"}}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestMerge_wrong_type(t *testing.T) {
+	if _, err := Merge(Layer{Source: "x", Values: map[string]any{KeySections: 3}}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestMerge_token_budget(t *testing.T) {
+	tests := []struct {
+		value   any
+		want    int
+		wantErr bool
+	}{
+		{int64(80000), 80000, false}, // TOML
+		{50000, 50000, false},        // YAML and flags
+		{" 0 ", 0, false},            // environment
+		{"lots", 0, true},
+		{-1, 0, true},
+		{1.5, 0, true},
+	}
+
+	for _, tt := range tests {
+		s, err := Merge(Layer{Source: "x", Values: map[string]any{KeyTokenBudget: tt.value}})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v: expected error, got nil", tt.value)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s.TokenBudget != tt.want {
+			t.Errorf("%v: got %d, want %d", tt.value, s.TokenBudget, tt.want)
+		}
+	}
+}
+
+func TestLoadRepo_components(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".herald.toml"), `
+[components.api]
+tag_prefix = "api/"
+paths = ["services/api/**", "libs/shared/**"]
+changelog = "services/api/CHANGELOG.md"
+
+[components.web]
+tag_prefix = "web/"
+`)
+
+	l, err := LoadRepo(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := Merge(l)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	api := s.Components["api"]
+	if api.TagPrefix != "api/" || !slices.Equal(api.Paths, []string{"services/api/**", "libs/shared/**"}) ||
+		api.Changelog != "services/api/CHANGELOG.md" {
+		t.Errorf("api = %+v", api)
+	}
+
+	if web := s.Components["web"]; web.TagPrefix != "web/" || len(web.Paths) != 0 {
+		t.Errorf("web = %+v", web)
+	}
+
+	if got := Format(s.Components); got != "api (api/*), web (web/*)" {
+		t.Errorf("Format = %q", got)
+	}
+}
+
+func TestMerge_invalid_components(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"not a table", "api"},
+		{"component not a table", map[string]any{"api": "api/"}},
+		{"missing prefix", map[string]any{"api": map[string]any{"paths": []any{"api/**"}}}},
+		{"unknown field", map[string]any{"api": map[string]any{"tag_prefix": "api/", "path": "api/**"}}},
+		{"paths not a list", map[string]any{"api": map[string]any{"tag_prefix": "api/", "paths": 3}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := Merge(Layer{Source: "x", Values: map[string]any{KeyComponents: tt.value}}); err == nil {
+			t.Errorf("%s: expected error, got nil", tt.name)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Package diff computes line-based unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+// Line kinds of an edit script, written as the unified diff line prefix.
+const (
+	opEqual  = ' '
+	opDelete = '-'
+	opInsert = '+'
+)
+
+// edit is one line of an edit script turning a into b.
+type edit struct {
+	kind byte
+	text string
+	// aLine and bLine are the 1-based line numbers before the edit in a and b.
+	aLine, bLine int
+}
+
+// Unified returns a unified diff from a to b with file headers labeled fromName and toName,
+// or "" if the texts have the same lines.
+func Unified(fromName, toName, a, b string) string {
+	edits := compute(splitLines(a), splitLines(b))
+
+	hunks := group(edits)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for _, h := range hunks {
+		aStart, bStart := h[0].aLine, h[0].bLine
+		aCount, bCount := 0, 0
+
+		for _, e := range h {
+			if e.kind != opInsert {
+				aCount++
+			}
+
+			if e.kind != opDelete {
+				bCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", span(aStart, aCount), span(bStart, bCount))
+
+		for _, e := range h {
+			out.WriteByte(e.kind)
+			out.WriteString(e.text)
+			out.WriteByte('\n')
+		}
+	}
+
+	return out.String()
+}
+
+// compute builds an edit script from the longest common subsequence of lines.
+func compute(a, b []string) []edit {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []edit
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{opEqual, a[i], i + 1, j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{opDelete, a[i], i + 1, j + 1})
+			i++
+		default:
+			edits = append(edits, edit{opInsert, b[j], i + 1, j + 1})
+			j++
+		}
+	}
+
+	return edits
+}
+
+// group splits the edit script into hunks of changes with surrounding context.
+func group(edits []edit) [][]edit {
+	var hunks [][]edit
+
+	start, end := -1, -1
+
+	for i, e := range edits {
+		if e.kind == opEqual {
+			continue
+		}
+
+		lo := max(0, i-contextLines)
+		if start >= 0 && lo <= end {
+			end = min(len(edits), i+contextLines+1)
+
+			continue
+		}
+
+		if start >= 0 {
+			hunks = append(hunks, edits[start:end])
+		}
+
+		start, end = lo, min(len(edits), i+contextLines+1)
+	}
+
+	if start >= 0 {
+		hunks = append(hunks, edits[start:end])
+	}
+
+	return hunks
+}
+
+// span formats a hunk range; empty ranges point at the line before them.
+func span(start, count int) string {
+	if count == 0 {
+		start--
+	}
+
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,39 @@
+package diff
+
+import "testing"
+
+func TestUnified_identical(t *testing.T) {
+	if got := Unified("a", "b", "x\ny\n", "x\ny"); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+}
+
+func TestUnified_change(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+
+	want := "--- current\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+
+	if got := Unified("current", "new", a, b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_separate_hunks(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ\n"
+
+	want := "--- x\n+++ y\n@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n d\n@@ -7,4 +7,4 @@\n g\n h\n i\n-j\n+J\n"
+
+	if got := Unified("x", "y", a, b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_from_empty(t *testing.T) {
+	want := "--- x\n+++ y\n@@ -0,0 +1,2 @@\n+new\n+text\n"
+
+	if got := Unified("x", "y", "", "new\ntext\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
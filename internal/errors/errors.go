@@ -10,6 +10,7 @@ const (
 	ExitConfig      = 2
 	ExitEnvironment = 3
 	ExitUserAbort   = 4
+	ExitTimeout     = 5
 )
 
 // AppError represents an application error with an exit code.
@@ -31,6 +32,24 @@ func (e *AppError) Unwrap() error {
 	return e.Cause
 }
 
+// Category returns a short name for the exit code (e.g. "config"), as used in JSON output.
+func (e *AppError) Category() string {
+	switch e.ExitCode {
+	case ExitSuccess:
+		return "success"
+	case ExitConfig:
+		return "config"
+	case ExitEnvironment:
+		return "environment"
+	case ExitUserAbort:
+		return "user_abort"
+	case ExitTimeout:
+		return "timeout"
+	default:
+		return "runtime"
+	}
+}
+
 // Runtime creates a runtime error (exit code 1).
 func Runtime(msg string, cause error) *AppError {
 	return &AppError{Message: msg, ExitCode: ExitRuntime, Cause: cause}
@@ -50,3 +69,13 @@ func Environment(msg string, cause error) *AppError {
 func UserAbort() *AppError {
 	return &AppError{Message: "operation cancelled by user", ExitCode: ExitUserAbort}
 }
+
+// Interrupted creates a user abort error (exit code 4) for a SIGINT or SIGTERM.
+func Interrupted() *AppError {
+	return &AppError{Message: "interrupted", ExitCode: ExitUserAbort}
+}
+
+// Timeout creates a timeout error (exit code 5).
+func Timeout(msg string, cause error) *AppError {
+	return &AppError{Message: msg, ExitCode: ExitTimeout, Cause: cause}
+}
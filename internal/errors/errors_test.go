@@ -59,6 +59,27 @@ func TestUserAbort(t *testing.T) {
 	}
 }
 
+func TestInterrupted(t *testing.T) {
+	err := Interrupted()
+
+	if err.ExitCode != ExitUserAbort {
+		t.Errorf("ExitCode = %d, want %d", err.ExitCode, ExitUserAbort)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	cause := fmt.Errorf("context deadline exceeded")
+	err := Timeout("generate timed out after 10m0s", cause)
+
+	if err.ExitCode != ExitTimeout {
+		t.Errorf("ExitCode = %d, want %d", err.ExitCode, ExitTimeout)
+	}
+
+	if err.Cause != cause {
+		t.Errorf("Cause = %v, want %v", err.Cause, cause)
+	}
+}
+
 func TestError_with_cause(t *testing.T) {
 	cause := fmt.Errorf("underlying")
 	err := Runtime("top-level", cause)
@@ -93,3 +114,23 @@ func TestUnwrap_nil(t *testing.T) {
 		t.Errorf("Unwrap() = %v, want nil", got)
 	}
 }
+
+func TestCategory(t *testing.T) {
+	tests := []struct {
+		err  *AppError
+		want string
+	}{
+		{Runtime("x", nil), "runtime"},
+		{Config("x"), "config"},
+		{Environment("x", nil), "environment"},
+		{UserAbort(), "user_abort"},
+		{Interrupted(), "user_abort"},
+		{Timeout("x", nil), "timeout"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Category(); got != tt.want {
+			t.Errorf("Category() for exit code %d = %q, want %q", tt.err.ExitCode, got, tt.want)
+		}
+	}
+}
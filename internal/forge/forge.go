@@ -0,0 +1,212 @@
+// Package forge provides access to releases on code hosting platforms
+// (GitHub, GitLab, Gitea and Forgejo), or to plain git tags.
+package forge
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/git"
+)
+
+// Supported forge kinds (values of the --forge flag).
+const (
+	KindGitHub  = "github"
+	KindGitLab  = "gitlab"
+	KindGitea   = "gitea"
+	KindForgejo = "forgejo"
+	// KindGit reads releases from local tags and never publishes.
+	KindGit = "git"
+)
+
+// Kinds lists all supported forge kinds in display order.
+var Kinds = []string{KindGitHub, KindGitLab, KindGitea, KindForgejo, KindGit}
+
+// Release represents a release on a forge.
+type Release struct {
+	TagName      string    `json:"tagName"`
+	PublishedAt  time.Time `json:"publishedAt"`
+	IsDraft      bool      `json:"isDraft"`
+	IsPrerelease bool      `json:"isPrerelease"`
+}
+
+// RepoInfo holds repository metadata.
+type RepoInfo struct {
+	Name          string `json:"name"`
+	NameWithOwner string `json:"nameWithOwner"`
+	WebURL        string `json:"url"`
+}
+
+// Provider performs the release operations herald needs on a forge.
+type Provider interface {
+	// Name returns the forge name used in messages (e.g. "GitHub").
+	Name() string
+	// CheckAuth verifies that the provider can access the repository.
+	CheckAuth(ctx context.Context) error
+	// ListReleases returns all releases for the repository.
+	ListReleases(ctx context.Context) ([]Release, error)
+	// GetRepoInfo returns the repository name, owner/name and web URL.
+	GetRepoInfo(ctx context.Context) (*RepoInfo, error)
+	// GetReleaseBody returns the current notes of the release for tag.
+	GetReleaseBody(ctx context.Context, tag string) (string, error)
+	// UpdateReleaseBody replaces the notes of the release for tag with the contents of notesFile.
+	UpdateReleaseBody(ctx context.Context, tag, notesFile string) error
+	// CompareURL returns the web page comparing prevTag with tag.
+	CompareURL(repo *RepoInfo, prevTag, tag string) string
+}
+
+// New returns the provider for the given kind.
+// An empty kind is detected from the host of the origin remote; repositories
+// without a recognizable remote default to GitHub.
+func New(ctx context.Context, kind string) (Provider, error) {
+	remote, remoteErr := originRemote(ctx)
+
+	if kind == "" {
+		detected, err := detectKind(remote)
+		if err != nil {
+			return nil, err
+		}
+
+		kind = detected
+	}
+
+	switch kind {
+	case KindGitHub:
+		return newGitHub(remote)
+	case KindGitLab, KindGitea, KindForgejo:
+		if remote == nil {
+			return nil, errors.Environment("cannot determine repository from origin remote", remoteErr)
+		}
+
+		if kind == KindGitLab {
+			return newGitLab(remote), nil
+		}
+
+		return newGitea(remote), nil
+	case KindGit:
+		return newGitTags(remote), nil
+	default:
+		return nil, errors.Config("unknown forge " + kind + " (supported: " + strings.Join(Kinds, ", ") + ")")
+	}
+}
+
+// originRemote parses the origin remote, returning nil and the cause if it is missing or unrecognized.
+func originRemote(ctx context.Context) (*remoteRepo, error) {
+	remoteURL, err := git.RemoteURL(ctx, "origin")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRemoteURL(remoteURL)
+}
+
+// detectKind guesses the forge from the remote host name.
+// Self-hosted instances are recognized when their host name mentions the product,
+// or when running inside that forge's CI.
+func detectKind(remote *remoteRepo) (string, error) {
+	if remote == nil {
+		return KindGitHub, nil
+	}
+
+	host := strings.ToLower(remote.Host)
+
+	switch {
+	case strings.Contains(host, "github"):
+		return KindGitHub, nil
+	case strings.Contains(host, "gitlab"), os.Getenv("CI_SERVER_HOST") == remote.Host:
+		return KindGitLab, nil
+	case host == "codeberg.org", strings.Contains(host, "forgejo"):
+		return KindForgejo, nil
+	case strings.Contains(host, "gitea"):
+		return KindGitea, nil
+	default:
+		return "", errors.Config("cannot detect forge for host " + remote.Host +
+			"; use --forge (" + strings.Join(Kinds, ", ") + ")")
+	}
+}
+
+// compareRange formats the "prev...tag" part of a compare URL. Tags are escaped for use
+// in a path, but slashes (as in monorepo tags like "api/v1.2.0") are kept.
+func compareRange(prevTag, tag string) string {
+	escape := func(ref string) string {
+		return strings.ReplaceAll(url.PathEscape(ref), "%2F", "/")
+	}
+
+	return escape(prevTag) + "..." + escape(tag)
+}
+
+func firstEnv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// FindPreviousRelease finds the release published immediately before the given tag.
+// The tagValidator function is used to filter releases to only those with valid git tags.
+// Drafts have no publish date: they are never chosen as the previous release, and a
+// draft target follows every published release.
+// Returns nil (without error) if no valid previous release is found.
+func FindPreviousRelease(releases []Release, tag string, tagValidator func(string) bool) (*Release, error) {
+	// Sort a copy to avoid mutating the caller's slice
+	sorted := slices.Clone(releases)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].PublishedAt, sorted[j].PublishedAt
+		if a.IsZero() || b.IsZero() {
+			return a.IsZero() && !b.IsZero()
+		}
+
+		return a.After(b)
+	})
+
+	// Find target release index
+	targetIdx := -1
+	for i, r := range sorted {
+		if r.TagName == tag {
+			targetIdx = i
+
+			break
+		}
+	}
+
+	if targetIdx == -1 {
+		return nil, errors.Runtime("release "+tag+" not found", nil)
+	}
+
+	// Find the next release with a valid git tag
+	for i := targetIdx + 1; i < len(sorted); i++ {
+		if sorted[i].IsDraft || sorted[i].PublishedAt.IsZero() {
+			continue
+		}
+
+		if tagValidator == nil || tagValidator(sorted[i].TagName) {
+			return &sorted[i], nil
+		}
+	}
+
+	// No valid previous release found
+	return nil, nil
+}
+
+// GetLatestRelease returns the most recently published release.
+func GetLatestRelease(releases []Release) (*Release, error) {
+	if len(releases) == 0 {
+		return nil, errors.Runtime("no releases found", nil)
+	}
+
+	sorted := slices.Clone(releases)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].PublishedAt.After(sorted[j].PublishedAt)
+	})
+
+	return &sorted[0], nil
+}
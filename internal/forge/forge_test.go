@@ -0,0 +1,265 @@
+package forge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindPreviousRelease_basic(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v3.0", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	got, err := FindPreviousRelease(releases, "v3.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.TagName != "v2.0" {
+		t.Errorf("got %q, want %q", got.TagName, "v2.0")
+	}
+}
+
+func TestFindPreviousRelease_first_release(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	got, err := FindPreviousRelease(releases, "v1.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestFindPreviousRelease_tag_not_found(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	_, err := FindPreviousRelease(releases, "v9.0", nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestFindPreviousRelease_skips_invalid_tags(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0-gone", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v3.0", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	validator := func(tag string) bool { return tag != "v2.0-gone" }
+
+	got, err := FindPreviousRelease(releases, "v3.0", validator)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.TagName != "v1.0" {
+		t.Errorf("got %q, want %q", got.TagName, "v1.0")
+	}
+}
+
+func TestFindPreviousRelease_all_previous_invalid(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.0-gone", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	validator := func(tag string) bool { return tag != "v1.0-gone" }
+
+	got, err := FindPreviousRelease(releases, "v2.0", validator)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestGetLatestRelease_basic(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v3.0", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	got, err := GetLatestRelease(releases)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.TagName != "v3.0" {
+		t.Errorf("got %q, want %q", got.TagName, "v3.0")
+	}
+}
+
+func TestGetLatestRelease_empty(t *testing.T) {
+	_, err := GetLatestRelease(nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   bool
+	}{
+		{"non-200 OK status code: 429 Too Many Requests body: ...", true},
+		{"429", true},
+		{"HTTP 503: Service Unavailable (https://api.github.com/graphql)", true},
+		{"not found", false},
+		{"HTTP 404: Not Found", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got := isTransient(tt.stderr)
+		if got != tt.want {
+			t.Errorf("isTransient(%q) = %v, want %v", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestParseReleasePages(t *testing.T) {
+	pages := `[{"tag_name":"v2.0","published_at":"2025-02-01T00:00:00Z","prerelease":true}]
+[{"tag_name":"v1.0","published_at":"2025-01-01T00:00:00Z"},{"tag_name":"v3.0","draft":true,"published_at":null}]`
+
+	got, err := parseReleasePages([]byte(pages))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 3 || got[0].TagName != "v2.0" || !got[0].IsPrerelease || !got[2].IsDraft {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestGHClient_ListReleases_paginates(t *testing.T) {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$*" in
+"api --paginate repos/{owner}/{repo}/releases?per_page=100") printf '[{"tag_name":"v2.0"}]\n[{"tag_name":"v1.0"}]\n' ;;
+*) echo "unexpected arguments: $*" >&2; exit 1 ;;
+esac
+`
+
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", dir)
+
+	got, err := (&ghClient{}).ListReleases(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || got[0].TagName != "v2.0" || got[1].TagName != "v1.0" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   remoteRepo
+	}{
+		{"https://github.com/owner/repo.git", remoteRepo{"github.com", "https://github.com", "owner", "repo"}},
+		{"https://github.com/owner/repo", remoteRepo{"github.com", "https://github.com", "owner", "repo"}},
+		{"git@github.com:owner/repo.git", remoteRepo{"github.com", "https://github.com", "owner", "repo"}},
+		{"ssh://git@ghe.corp:22/team/tool.git", remoteRepo{"ghe.corp", "https://ghe.corp", "team", "tool"}},
+		{"git@gitlab.example.com:group/sub/app.git",
+			remoteRepo{"gitlab.example.com", "https://gitlab.example.com", "group/sub", "app"}},
+		{"http://localhost:3000/me/proj.git", remoteRepo{"localhost:3000", "http://localhost:3000", "me", "proj"}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseRemoteURL(tt.remote)
+		if err != nil {
+			t.Errorf("parseRemoteURL(%q) error: %v", tt.remote, err)
+
+			continue
+		}
+
+		if *got != tt.want {
+			t.Errorf("parseRemoteURL(%q) = %+v, want %+v", tt.remote, *got, tt.want)
+		}
+	}
+}
+
+func TestParseRemoteURL_invalid(t *testing.T) {
+	for _, remote := range []string{"/local/path/repo", "https://github.com/", "git@host:repo"} {
+		if _, err := parseRemoteURL(remote); err == nil {
+			t.Errorf("parseRemoteURL(%q): expected error, got nil", remote)
+		}
+	}
+}
+
+func TestDetectKind(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"github.com", KindGitHub},
+		{"github.corp.example", KindGitHub},
+		{"gitlab.com", KindGitLab},
+		{"gitlab.internal:8443", KindGitLab},
+		{"codeberg.org", KindForgejo},
+		{"forgejo.example.org", KindForgejo},
+		{"gitea.example.org", KindGitea},
+	}
+
+	for _, tt := range tests {
+		got, err := detectKind(&remoteRepo{Host: tt.host})
+		if err != nil {
+			t.Errorf("detectKind(%q) error: %v", tt.host, err)
+
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("detectKind(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestDetectKind_no_remote_defaults_to_github(t *testing.T) {
+	got, err := detectKind(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != KindGitHub {
+		t.Errorf("got %q, want %q", got, KindGitHub)
+	}
+}
+
+func TestDetectKind_unknown_host(t *testing.T) {
+	t.Setenv("CI_SERVER_HOST", "")
+
+	if _, err := detectKind(&remoteRepo{Host: "git.example.com"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestGitHubAPIURL(t *testing.T) {
+	if got := githubAPIURL(&remoteRepo{Host: "github.com"}); got != "https://api.github.com" {
+		t.Errorf("got %q", got)
+	}
+
+	if got := githubAPIURL(&remoteRepo{Host: "ghe.corp", BaseURL: "https://ghe.corp"}); got != "https://ghe.corp/api/v3" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,95 @@
+package forge
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/git"
+	"github.com/AndreyAkinshin/herald/internal/semver"
+)
+
+// gitTags treats the tags of the local repository as releases, for repositories
+// without releases on a forge. Notes cannot be published: they are written to files only.
+type gitTags struct {
+	// remote is the origin remote, used for compare links; nil if there is none.
+	remote *remoteRepo
+}
+
+func newGitTags(remote *remoteRepo) *gitTags {
+	return &gitTags{remote: remote}
+}
+
+// Name returns the provider name.
+func (c *gitTags) Name() string {
+	return "git tags"
+}
+
+// CheckAuth always succeeds: only the local repository is accessed.
+func (c *gitTags) CheckAuth(context.Context) error {
+	return nil
+}
+
+// ListReleases returns every tag as a published release dated by its creation.
+// Tags that are semantic versions with prerelease identifiers are marked as prereleases.
+func (c *gitTags) ListReleases(ctx context.Context) ([]Release, error) {
+	tags, err := git.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, 0, len(tags))
+
+	for _, t := range tags {
+		v, ok := semver.Parse(t.Name)
+		releases = append(releases, Release{TagName: t.Name, PublishedAt: t.Date, IsPrerelease: ok && v.IsPrerelease()})
+	}
+
+	return releases, nil
+}
+
+// GetRepoInfo names the repository after its root directory, and takes owner and
+// web URL from the origin remote when available.
+func (c *gitTags) GetRepoInfo(context.Context) (*RepoInfo, error) {
+	root, err := git.FindRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RepoInfo{Name: filepath.Base(root), NameWithOwner: filepath.Base(root)}
+
+	if c.remote != nil {
+		info.Name, info.NameWithOwner, info.WebURL = c.remote.Name, c.remote.Path(), c.remote.WebURL()
+	}
+
+	return info, nil
+}
+
+// GetReleaseBody returns an empty body: tags carry no release notes.
+func (c *gitTags) GetReleaseBody(context.Context, string) (string, error) {
+	return "", nil
+}
+
+// UpdateReleaseBody fails: there is no release to update.
+func (c *gitTags) UpdateReleaseBody(_ context.Context, tag, _ string) error {
+	return errors.Config("cannot update release " + tag + ": --forge " + KindGit + " only writes notes to files")
+}
+
+// CompareURL returns the compare page on the forge hosting the origin remote,
+// or "" if the remote is missing or its forge is not recognized.
+func (c *gitTags) CompareURL(repo *RepoInfo, prevTag, tag string) string {
+	if c.remote == nil || repo.WebURL == "" {
+		return ""
+	}
+
+	kind, err := detectKind(c.remote)
+	if err != nil {
+		return ""
+	}
+
+	if kind == KindGitLab {
+		return repo.WebURL + "/-/compare/" + compareRange(prevTag, tag)
+	}
+
+	return repo.WebURL + "/compare/" + compareRange(prevTag, tag)
+}
@@ -0,0 +1,32 @@
+package forge
+
+import "fmt"
+
+// giteaMaxPageSize is the default maximum page size of Gitea and Forgejo instances.
+const giteaMaxPageSize = 50
+
+// newGitea returns a client for Gitea and Forgejo, whose release API mirrors GitHub's.
+// The token is read from GITEA_TOKEN or FORGEJO_TOKEN.
+func newGitea(remote *remoteRepo) *githubAPI {
+	token := firstEnv("GITEA_TOKEN", "FORGEJO_TOKEN")
+
+	c := newGiteaAPI(remote.BaseURL+"/api/v1", token, remote.Owner, remote.Name)
+	if token == "" {
+		c.missingToken = "GITEA_TOKEN (or FORGEJO_TOKEN)"
+	}
+
+	return c
+}
+
+func newGiteaAPI(baseURL, token, owner, repo string) *githubAPI {
+	headers := map[string]string{
+		"Authorization": "token " + token,
+	}
+
+	return &githubAPI{
+		api:       newRESTClient("Gitea", baseURL, headers),
+		owner:     owner,
+		repo:      repo,
+		pageQuery: fmt.Sprintf("limit=%d", giteaMaxPageSize),
+	}
+}
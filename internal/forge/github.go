@@ -0,0 +1,50 @@
+package forge
+
+import (
+	"os"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// newGitHub returns a native REST client when a token is available in GH_TOKEN or
+// GITHUB_TOKEN, and falls back to the gh CLI otherwise.
+//
+// The repository is taken from GH_REPO or GITHUB_REPOSITORY (owner/repo), or from
+// the origin remote. GITHUB_API_URL overrides the API endpoint (GitHub Enterprise).
+func newGitHub(remote *remoteRepo) (Provider, error) {
+	token := firstEnv("GH_TOKEN", "GITHUB_TOKEN")
+	if token == "" {
+		return &ghClient{}, nil
+	}
+
+	repo := remote
+
+	if slug := firstEnv("GH_REPO", "GITHUB_REPOSITORY"); slug != "" {
+		var err error
+
+		if repo, err = parseRepoSlug(slug); err != nil {
+			return nil, errors.Config(err.Error())
+		}
+	}
+
+	if repo == nil {
+		return nil, errors.Environment("cannot determine GitHub repository; set GH_REPO or add an origin remote", nil)
+	}
+
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = githubAPIURL(repo)
+	}
+
+	return newGitHubAPI(apiURL, token, repo.Owner, repo.Name), nil
+}
+
+// githubAPIURL returns the REST API root for a GitHub host.
+// GitHub Enterprise Server exposes the API under /api/v3.
+func githubAPIURL(repo *remoteRepo) string {
+	if repo.Host == "" || repo.Host == defaultHost {
+		return "https://api.github.com"
+	}
+
+	return repo.BaseURL + "/api/v3"
+}
@@ -0,0 +1,192 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// githubAPI talks to the GitHub REST API directly using a token.
+// Gitea and Forgejo expose a compatible release API and reuse this implementation.
+type githubAPI struct {
+	api   *restClient
+	owner string
+	repo  string
+	// pageQuery is the query string requesting the largest page size.
+	pageQuery string
+	// missingToken names the environment variable to set when no token was found.
+	missingToken string
+}
+
+func newGitHubAPI(baseURL, token, owner, repo string) *githubAPI {
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"Authorization":        "Bearer " + token,
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+
+	return &githubAPI{
+		api:       newRESTClient("GitHub", baseURL, headers),
+		owner:     owner,
+		repo:      repo,
+		pageQuery: fmt.Sprintf("per_page=%d", apiPageSize),
+	}
+}
+
+// githubRelease is a release as returned by the GitHub (and Gitea) REST API.
+type githubRelease struct {
+	ID          int64     `json:"id"`
+	TagName     string    `json:"tag_name"`
+	PublishedAt time.Time `json:"published_at"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	Body        string    `json:"body"`
+}
+
+type githubRepo struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+func (c *githubAPI) Name() string { return c.api.name }
+
+// CheckAuth verifies the token can read the repository.
+// The repository endpoint is used rather than /user because Actions tokens cannot read /user.
+func (c *githubAPI) CheckAuth(ctx context.Context) error {
+	if c.missingToken != "" {
+		return errors.Environment(c.missingToken+" is not set", nil)
+	}
+
+	if _, err := c.api.do(ctx, http.MethodGet, c.repoPath(""), nil, nil); err != nil {
+		return errors.Environment(c.api.name+" API not accessible for "+c.owner+"/"+c.repo, err)
+	}
+
+	return nil
+}
+
+// ListReleases returns all releases, following pagination links.
+func (c *githubAPI) ListReleases(ctx context.Context) ([]Release, error) {
+	all, err := c.listAPIReleases(ctx)
+	if err != nil {
+		return nil, errors.Runtime("failed to list releases", err)
+	}
+
+	return toReleases(all), nil
+}
+
+// toReleases converts releases of the REST API.
+func toReleases(all []githubRelease) []Release {
+	releases := make([]Release, 0, len(all))
+	for _, r := range all {
+		releases = append(releases, Release{
+			TagName:      r.TagName,
+			PublishedAt:  r.PublishedAt,
+			IsDraft:      r.Draft,
+			IsPrerelease: r.Prerelease,
+		})
+	}
+
+	return releases
+}
+
+func (c *githubAPI) listAPIReleases(ctx context.Context) ([]githubRelease, error) {
+	var all []githubRelease
+
+	err := c.api.getAll(ctx, c.repoPath("/releases?"+c.pageQuery), func(page []byte) error {
+		var releases []githubRelease
+		if err := json.Unmarshal(page, &releases); err != nil {
+			return err
+		}
+
+		all = append(all, releases...)
+
+		return nil
+	})
+
+	return all, err
+}
+
+// GetRepoInfo returns the repository name, owner/name and web URL.
+func (c *githubAPI) GetRepoInfo(ctx context.Context) (*RepoInfo, error) {
+	var repo githubRepo
+	if _, err := c.api.do(ctx, http.MethodGet, c.repoPath(""), nil, &repo); err != nil {
+		return nil, errors.Runtime("failed to get repository info", err)
+	}
+
+	return &RepoInfo{Name: repo.Name, NameWithOwner: repo.FullName, WebURL: repo.HTMLURL}, nil
+}
+
+// GetReleaseBody returns the body of the release for tag.
+func (c *githubAPI) GetReleaseBody(ctx context.Context, tag string) (string, error) {
+	release, err := c.findRelease(ctx, tag)
+	if err != nil {
+		return "", errors.Runtime("failed to get release "+tag, err)
+	}
+
+	return release.Body, nil
+}
+
+// UpdateReleaseBody replaces the body of the release for tag with the contents of notesFile.
+func (c *githubAPI) UpdateReleaseBody(ctx context.Context, tag, notesFile string) error {
+	body, err := os.ReadFile(notesFile)
+	if err != nil {
+		return errors.Runtime("failed to read notes file", err)
+	}
+
+	release, err := c.findRelease(ctx, tag)
+	if err != nil {
+		return errors.Runtime("failed to update release "+tag, err)
+	}
+
+	payload := map[string]string{"body": string(body)}
+	path := c.repoPath(fmt.Sprintf("/releases/%d", release.ID))
+	if _, err := c.api.do(ctx, http.MethodPatch, path, payload, nil); err != nil {
+		return errors.Runtime("failed to update release "+tag, err)
+	}
+
+	return nil
+}
+
+// CompareURL returns the web page comparing two tags.
+func (c *githubAPI) CompareURL(repo *RepoInfo, prevTag, tag string) string {
+	return repo.WebURL + "/compare/" + compareRange(prevTag, tag)
+}
+
+// findRelease looks up the release for a tag.
+// The by-tag endpoint does not return drafts, so the full list is scanned as a fallback.
+func (c *githubAPI) findRelease(ctx context.Context, tag string) (*githubRelease, error) {
+	var release githubRelease
+
+	_, err := c.api.do(ctx, http.MethodGet, c.repoPath("/releases/tags/"+url.PathEscape(tag)), nil, &release)
+	if err == nil {
+		return &release, nil
+	}
+
+	if !isNotFound(err) {
+		return nil, err
+	}
+
+	all, err := c.listAPIReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range all {
+		if r.TagName == tag {
+			return &r, nil
+		}
+	}
+
+	return nil, fmt.Errorf("release %s not found", tag)
+}
+
+func (c *githubAPI) repoPath(suffix string) string {
+	return "/repos/" + url.PathEscape(c.owner) + "/" + url.PathEscape(c.repo) + suffix
+}
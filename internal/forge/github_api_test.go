@@ -0,0 +1,189 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitHubAPI_ListReleases_paginates(t *testing.T) {
+	var srv *httptest.Server
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer token")
+		}
+
+		if r.URL.Path != "/repos/owner/repo/releases" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/releases?page=2>; rel="next", `+
+				`<%s/repos/owner/repo/releases?page=2>; rel="last"`, srv.URL, srv.URL))
+			_, _ = w.Write([]byte(`[{"id":2,"tag_name":"v2.0","published_at":"2025-02-01T00:00:00Z"}]`))
+		case "2":
+			_, _ = w.Write([]byte(`[{"id":1,"tag_name":"v1.0","published_at":"2025-01-01T00:00:00Z","prerelease":true}]`))
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}))
+	defer srv.Close()
+
+	got, err := newGitHubAPI(srv.URL, "token", "owner", "repo").ListReleases(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d releases, want 2", len(got))
+	}
+
+	if got[0].TagName != "v2.0" || got[1].TagName != "v1.0" {
+		t.Errorf("got tags %q, %q", got[0].TagName, got[1].TagName)
+	}
+
+	if !got[1].IsPrerelease {
+		t.Error("expected v1.0 to be a prerelease")
+	}
+}
+
+func TestGitHubAPI_GetRepoInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"repo","full_name":"owner/repo","html_url":"https://github.com/owner/repo"}`))
+	}))
+	defer srv.Close()
+
+	got, err := newGitHubAPI(srv.URL, "token", "owner", "repo").GetRepoInfo(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Name != "repo" || got.NameWithOwner != "owner/repo" || got.WebURL != "https://github.com/owner/repo" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestGitHubAPI_GetReleaseBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/releases/tags/v1.0" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		_, _ = w.Write([]byte(`{"id":1,"tag_name":"v1.0","body":"Hand-written notes"}`))
+	}))
+	defer srv.Close()
+
+	got, err := newGitHubAPI(srv.URL, "token", "owner", "repo").GetReleaseBody(t.Context(), "v1.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "Hand-written notes" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestGitHubAPI_CheckAuth_unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer srv.Close()
+
+	if err := newGitHubAPI(srv.URL, "bad", "owner", "repo").CheckAuth(t.Context()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestGitHubAPI_UpdateReleaseBody(t *testing.T) {
+	var patched string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/releases/tags/v1.0":
+			_, _ = w.Write([]byte(`{"id":42,"tag_name":"v1.0"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/owner/repo/releases/42":
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+
+			patched = body["body"]
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	notesFile := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(notesFile, []byte("## Features\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newGitHubAPI(srv.URL, "token", "owner", "repo")
+	if err := c.UpdateReleaseBody(t.Context(), "v1.0", notesFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if patched != "## Features\n" {
+		t.Errorf("patched body = %q", patched)
+	}
+}
+
+func TestGitHubAPI_UpdateReleaseBody_draft_fallback(t *testing.T) {
+	var patchedPath string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/owner/repo/releases/tags/v2.0":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+		case r.URL.Path == "/repos/owner/repo/releases":
+			_, _ = w.Write([]byte(`[{"id":7,"tag_name":"v2.0","draft":true}]`))
+		case r.Method == http.MethodPatch:
+			patchedPath = r.URL.Path
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	notesFile := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(notesFile, []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newGitHubAPI(srv.URL, "token", "owner", "repo")
+	if err := c.UpdateReleaseBody(t.Context(), "v2.0", notesFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if patchedPath != "/repos/owner/repo/releases/7" {
+		t.Errorf("patched %q, want /repos/owner/repo/releases/7", patchedPath)
+	}
+}
+
+func TestNextPageLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`,
+			"https://api.github.com/x?page=2"},
+		{`<https://api.github.com/x?page=1>; rel="prev"`, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := nextPageLink(tt.link); got != tt.want {
+			t.Errorf("nextPageLink(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
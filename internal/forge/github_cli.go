@@ -0,0 +1,154 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/proc"
+	"github.com/AndreyAkinshin/herald/internal/retry"
+)
+
+// ghClient talks to GitHub through the gh CLI, reusing its interactive authentication.
+type ghClient struct{}
+
+func (c *ghClient) Name() string { return "GitHub" }
+
+// CheckAuth verifies the gh CLI is installed and authenticated.
+func (c *ghClient) CheckAuth(ctx context.Context) error {
+	_, err := runGH(ctx, "auth", "status")
+	if err != nil {
+		return errors.Environment("gh CLI not available or not authenticated", err)
+	}
+
+	return nil
+}
+
+// ListReleases returns all releases for the current repository. gh release list caps
+// the number of releases, so the REST API is read page by page instead.
+func (c *ghClient) ListReleases(ctx context.Context) ([]Release, error) {
+	path := fmt.Sprintf("repos/{owner}/{repo}/releases?per_page=%d", apiPageSize)
+
+	stdout, err := runGH(ctx, "api", "--paginate", path)
+	if err != nil {
+		return nil, errors.Runtime("failed to list releases", err)
+	}
+
+	releases, err := parseReleasePages(stdout)
+	if err != nil {
+		return nil, errors.Runtime("failed to parse releases", err)
+	}
+
+	return releases, nil
+}
+
+// parseReleasePages parses the output of gh api --paginate: the JSON arrays of all
+// pages, one after another.
+func parseReleasePages(data []byte) ([]Release, error) {
+	var all []githubRelease
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var page []githubRelease
+		if err := dec.Decode(&page); err != nil {
+			return nil, err
+		}
+
+		all = append(all, page...)
+	}
+
+	return toReleases(all), nil
+}
+
+// GetReleaseBody returns the current release notes for a given tag.
+func (c *ghClient) GetReleaseBody(ctx context.Context, tag string) (string, error) {
+	stdout, err := runGH(ctx, "release", "view", tag, "--json", "body")
+	if err != nil {
+		return "", errors.Runtime("failed to get release "+tag, err)
+	}
+
+	var release struct {
+		Body string `json:"body"`
+	}
+	if err := json.Unmarshal(stdout, &release); err != nil {
+		return "", errors.Runtime("failed to parse release "+tag, err)
+	}
+
+	return release.Body, nil
+}
+
+// UpdateReleaseBody updates the release notes for a given tag.
+func (c *ghClient) UpdateReleaseBody(ctx context.Context, tag, notesFile string) error {
+	_, err := runGH(ctx, "release", "edit", tag, "--notes-file", notesFile)
+	if err != nil {
+		return errors.Runtime("failed to update release "+tag, err)
+	}
+
+	return nil
+}
+
+// GetRepoInfo returns the repository name, owner/name and web URL in a single gh call.
+func (c *ghClient) GetRepoInfo(ctx context.Context) (*RepoInfo, error) {
+	stdout, err := runGH(ctx, "repo", "view", "--json", "name,nameWithOwner,url")
+	if err != nil {
+		return nil, errors.Runtime("failed to get repository info", err)
+	}
+
+	var info RepoInfo
+	if err := json.Unmarshal(stdout, &info); err != nil {
+		return nil, errors.Runtime("failed to parse repository info", err)
+	}
+
+	return &info, nil
+}
+
+// CompareURL returns the web page comparing two tags.
+func (c *ghClient) CompareURL(repo *RepoInfo, prevTag, tag string) string {
+	return repo.WebURL + "/compare/" + compareRange(prevTag, tag)
+}
+
+// runGH executes a gh CLI command, retrying transient failures such as rate limiting
+// (HTTP 429) or server errors. Returns stdout bytes on success, or an error containing
+// stderr output.
+func runGH(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout []byte
+
+	err := retry.Do(ctx, retryPolicy("GitHub"), func(ctx context.Context) error {
+		cmd := proc.Command(ctx, "gh", args...)
+
+		var out, stderr bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			stderrStr := strings.TrimSpace(stderr.String())
+			if stderrStr == "" {
+				return err
+			}
+
+			if isTransient(stderrStr) {
+				return retry.Retryable(fmt.Errorf("%s", stderrStr), 0)
+			}
+
+			return fmt.Errorf("%s", stderrStr)
+		}
+
+		stdout = out.Bytes()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stdout, nil
+}
+
+// isTransient checks if a gh CLI error indicates rate limiting, overload or a network
+// failure, which may pass when retried.
+func isTransient(stderr string) bool {
+	return retry.IsTransientMessage(stderr)
+}
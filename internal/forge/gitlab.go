@@ -0,0 +1,147 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// gitlabAPI talks to the GitLab REST API (v4) using a personal, project or group access token.
+type gitlabAPI struct {
+	api *restClient
+	// project is the namespaced project path, e.g. "group/subgroup/repo".
+	project      string
+	missingToken bool
+}
+
+// newGitLab returns a client for the project of the given remote.
+// The token is read from GITLAB_TOKEN. Inside GitLab CI, CI_API_V4_URL is used as the
+// API root when it belongs to the same host.
+func newGitLab(remote *remoteRepo) *gitlabAPI {
+	apiURL := remote.BaseURL + "/api/v4"
+	if ciURL := os.Getenv("CI_API_V4_URL"); ciURL != "" && os.Getenv("CI_SERVER_HOST") == remote.Host {
+		apiURL = ciURL
+	}
+
+	token := os.Getenv("GITLAB_TOKEN")
+
+	c := newGitLabAPI(apiURL, token, remote.Path())
+	c.missingToken = token == ""
+
+	return c
+}
+
+func newGitLabAPI(baseURL, token, project string) *gitlabAPI {
+	headers := map[string]string{"PRIVATE-TOKEN": token}
+
+	return &gitlabAPI{api: newRESTClient("GitLab", baseURL, headers), project: project}
+}
+
+type gitlabRelease struct {
+	TagName         string    `json:"tag_name"`
+	ReleasedAt      time.Time `json:"released_at"`
+	UpcomingRelease bool      `json:"upcoming_release"`
+	Description     string    `json:"description"`
+}
+
+type gitlabProject struct {
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+func (c *gitlabAPI) Name() string { return c.api.name }
+
+// CheckAuth verifies the token can read the project.
+func (c *gitlabAPI) CheckAuth(ctx context.Context) error {
+	if c.missingToken {
+		return errors.Environment("GITLAB_TOKEN is not set", nil)
+	}
+
+	if _, err := c.api.do(ctx, http.MethodGet, c.projectPath(""), nil, nil); err != nil {
+		return errors.Environment("GitLab API not accessible for "+c.project, err)
+	}
+
+	return nil
+}
+
+// ListReleases returns all releases, following pagination links.
+// GitLab has no drafts; upcoming releases (released_at in the future) are reported as prereleases.
+func (c *gitlabAPI) ListReleases(ctx context.Context) ([]Release, error) {
+	var releases []Release
+
+	path := c.projectPath(fmt.Sprintf("/releases?per_page=%d", apiPageSize))
+
+	err := c.api.getAll(ctx, path, func(page []byte) error {
+		var items []gitlabRelease
+		if err := json.Unmarshal(page, &items); err != nil {
+			return err
+		}
+
+		for _, r := range items {
+			releases = append(releases, Release{
+				TagName:      r.TagName,
+				PublishedAt:  r.ReleasedAt,
+				IsPrerelease: r.UpcomingRelease,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Runtime("failed to list releases", err)
+	}
+
+	return releases, nil
+}
+
+// GetRepoInfo returns the project path, namespaced path and web URL.
+func (c *gitlabAPI) GetRepoInfo(ctx context.Context) (*RepoInfo, error) {
+	var project gitlabProject
+	if _, err := c.api.do(ctx, http.MethodGet, c.projectPath(""), nil, &project); err != nil {
+		return nil, errors.Runtime("failed to get repository info", err)
+	}
+
+	return &RepoInfo{Name: project.Path, NameWithOwner: project.PathWithNamespace, WebURL: project.WebURL}, nil
+}
+
+// GetReleaseBody returns the description of the release for tag.
+func (c *gitlabAPI) GetReleaseBody(ctx context.Context, tag string) (string, error) {
+	var release gitlabRelease
+	path := c.projectPath("/releases/" + url.PathEscape(tag))
+	if _, err := c.api.do(ctx, http.MethodGet, path, nil, &release); err != nil {
+		return "", errors.Runtime("failed to get release "+tag, err)
+	}
+
+	return release.Description, nil
+}
+
+// UpdateReleaseBody replaces the description of the release for tag with the contents of notesFile.
+func (c *gitlabAPI) UpdateReleaseBody(ctx context.Context, tag, notesFile string) error {
+	body, err := os.ReadFile(notesFile)
+	if err != nil {
+		return errors.Runtime("failed to read notes file", err)
+	}
+
+	payload := map[string]string{"description": string(body)}
+	if _, err := c.api.do(ctx, http.MethodPut, c.projectPath("/releases/"+url.PathEscape(tag)), payload, nil); err != nil {
+		return errors.Runtime("failed to update release "+tag, err)
+	}
+
+	return nil
+}
+
+// CompareURL returns the web page comparing two tags.
+func (c *gitlabAPI) CompareURL(repo *RepoInfo, prevTag, tag string) string {
+	return repo.WebURL + "/-/compare/" + compareRange(prevTag, tag)
+}
+
+func (c *gitlabAPI) projectPath(suffix string) string {
+	return "/projects/" + url.PathEscape(c.project) + suffix
+}
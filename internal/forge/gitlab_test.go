@@ -0,0 +1,163 @@
+package forge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitLabAPI_ListReleases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "token" {
+			t.Errorf("PRIVATE-TOKEN = %q, want %q", got, "token")
+		}
+
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fsub%2Fapp/releases" {
+			t.Errorf("path = %q", r.URL.EscapedPath())
+		}
+
+		_, _ = w.Write([]byte(`[{"tag_name":"v2.0","released_at":"2025-02-01T00:00:00Z","upcoming_release":true},` +
+			`{"tag_name":"v1.0","released_at":"2025-01-01T00:00:00Z"}]`))
+	}))
+	defer srv.Close()
+
+	got, err := newGitLabAPI(srv.URL+"/api/v4", "token", "group/sub/app").ListReleases(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || got[0].TagName != "v2.0" || got[1].TagName != "v1.0" {
+		t.Fatalf("got %+v", got)
+	}
+
+	if !got[0].IsPrerelease {
+		t.Error("expected upcoming release to be reported as prerelease")
+	}
+}
+
+func TestGitLabAPI_GetRepoInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"path":"app","path_with_namespace":"group/sub/app",` +
+			`"web_url":"https://gitlab.example.com/group/sub/app"}`))
+	}))
+	defer srv.Close()
+
+	got, err := newGitLabAPI(srv.URL, "token", "group/sub/app").GetRepoInfo(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := RepoInfo{Name: "app", NameWithOwner: "group/sub/app", WebURL: "https://gitlab.example.com/group/sub/app"}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+}
+
+func TestGitLabAPI_GetReleaseBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/group%2Fapp/releases/v1.0" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+
+		_, _ = w.Write([]byte(`{"tag_name":"v1.0","description":"Notes"}`))
+	}))
+	defer srv.Close()
+
+	got, err := newGitLabAPI(srv.URL, "token", "group/app").GetReleaseBody(t.Context(), "v1.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "Notes" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestGitLabAPI_UpdateReleaseBody(t *testing.T) {
+	var description string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.EscapedPath() != "/projects/group%2Fapp/releases/api%2Fv1.0" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.EscapedPath())
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+
+		description = body["description"]
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	notesFile := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(notesFile, []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newGitLabAPI(srv.URL, "token", "group/app")
+	if err := c.UpdateReleaseBody(t.Context(), "api/v1.0", notesFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if description != "notes" {
+		t.Errorf("description = %q, want %q", description, "notes")
+	}
+}
+
+func TestGitLabAPI_CheckAuth_missing_token(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "")
+
+	c := newGitLab(&remoteRepo{Host: "gitlab.com", BaseURL: "https://gitlab.com", Owner: "g", Name: "r"})
+	if err := c.CheckAuth(t.Context()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCompareURL(t *testing.T) {
+	repo := &RepoInfo{WebURL: "https://example.com/o/r"}
+
+	tests := []struct {
+		provider Provider
+		want     string
+	}{
+		{&ghClient{}, "https://example.com/o/r/compare/v1...v2"},
+		{newGitHubAPI("", "t", "o", "r"), "https://example.com/o/r/compare/v1...v2"},
+		{newGiteaAPI("", "t", "o", "r"), "https://example.com/o/r/compare/v1...v2"},
+		{newGitLabAPI("", "t", "o/r"), "https://example.com/o/r/-/compare/v1...v2"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.provider.CompareURL(repo, "v1", "v2"); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.provider.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestGiteaAPI_ListReleases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("Authorization = %q, want %q", got, "token secret")
+		}
+
+		if got := r.URL.Query().Get("limit"); got != "50" {
+			t.Errorf("limit = %q, want 50", got)
+		}
+
+		_, _ = w.Write([]byte(`[{"id":1,"tag_name":"v1.0","published_at":"2025-01-01T00:00:00Z","draft":true}]`))
+	}))
+	defer srv.Close()
+
+	got, err := newGiteaAPI(srv.URL+"/api/v1", "secret", "me", "proj").ListReleases(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 1 || got[0].TagName != "v1.0" || !got[0].IsDraft {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,215 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/retry"
+)
+
+const (
+	apiPageSize    = 100
+	apiTimeout     = 30 * time.Second
+	apiMaxErrorLen = 512
+	// apiAttempts is the number of tries of a request that fails with a transient error.
+	apiAttempts     = 4
+	apiRetryWait    = 2 * time.Second
+	apiMaxRetryWait = time.Minute
+)
+
+// Output receives progress messages of the forge providers, such as retried requests.
+var Output io.Writer = os.Stdout
+
+// restClient is a minimal JSON REST client shared by the native forge providers.
+type restClient struct {
+	// name is the forge name used in messages (e.g. "GitHub").
+	name    string
+	baseURL string
+	headers map[string]string
+	http    *http.Client
+}
+
+func newRESTClient(name, baseURL string, headers map[string]string) *restClient {
+	return &restClient{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		headers: headers,
+		http:    &http.Client{Timeout: apiTimeout},
+	}
+}
+
+// apiError is a non-2xx response from a forge API.
+type apiError struct {
+	Forge      string
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s API returned %d: %s", e.Forge, e.StatusCode, e.Message)
+}
+
+// isNotFound reports whether err is an API 404 response.
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do performs an API request, retrying on rate limiting, server errors and network failures.
+// path may be relative to the base URL or an absolute URL (as found in pagination links).
+// The response JSON is decoded into out when non-nil.
+func (c *restClient) do(ctx context.Context, method, path string, body, out any) (http.Header, error) {
+	target := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		target = c.baseURL + path
+	}
+
+	var payload []byte
+
+	if body != nil {
+		var err error
+
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	var header http.Header
+
+	err := retry.Do(ctx, retryPolicy(c.name), func(ctx context.Context) error {
+		var err error
+
+		header, err = c.doOnce(ctx, method, target, payload, out)
+
+		return err
+	})
+
+	return header, err
+}
+
+// getAll fetches every page of a list endpoint by following rel="next" links.
+// decode is called with each page's raw JSON.
+func (c *restClient) getAll(ctx context.Context, path string, decode func([]byte) error) error {
+	for next := path; next != ""; {
+		var page json.RawMessage
+
+		header, err := c.do(ctx, http.MethodGet, next, nil, &page)
+		if err != nil {
+			return err
+		}
+
+		if err := decode(page); err != nil {
+			return err
+		}
+
+		next = nextPageLink(header.Get("Link"))
+	}
+
+	return nil
+}
+
+func (c *restClient) doOnce(ctx context.Context, method, target string, payload []byte, out any) (http.Header, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Network failures are transient; Do stops anyway once ctx is done
+		return nil, retry.Retryable(err, 0)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &apiError{Forge: c.name, StatusCode: resp.StatusCode, Message: apiErrorMessage(data)}
+
+		return nil, retry.ForResponse(apiErr, resp)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("failed to parse %s API response: %w", c.name, err)
+		}
+	}
+
+	return resp.Header, nil
+}
+
+// retryPolicy returns the retry policy for requests to the named forge, which tells
+// the user about every retry.
+func retryPolicy(name string) retry.Policy {
+	return retry.Policy{
+		Attempts:  apiAttempts,
+		BaseDelay: apiRetryWait,
+		MaxDelay:  apiMaxRetryWait,
+		OnRetry: func(err error, wait time.Duration) {
+			msg, _, _ := strings.Cut(err.Error(), "\n")
+			fmt.Fprintf(Output, "Request to %s failed (%s), retrying in %v...\n", name, msg, wait.Round(time.Second))
+		},
+	}
+}
+
+// apiErrorMessage extracts the "message" field of an error response, falling back to the raw body.
+func apiErrorMessage(data []byte) string {
+	var body struct {
+		Message string `json:"message"`
+	}
+
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		return body.Message
+	}
+
+	msg := strings.TrimSpace(string(data))
+	if len(msg) > apiMaxErrorLen {
+		msg = msg[:apiMaxErrorLen] + "..."
+	}
+
+	return msg
+}
+
+// nextPageLink returns the rel="next" URL from a Link header, or "" if there is none.
+func nextPageLink(link string) string {
+	for part := range strings.SplitSeq(link, ",") {
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(segments[0]), "<>")
+			}
+		}
+	}
+
+	return ""
+}
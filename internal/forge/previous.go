@@ -0,0 +1,249 @@
+package forge
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/git"
+	"github.com/AndreyAkinshin/herald/internal/glob"
+	"github.com/AndreyAkinshin/herald/internal/semver"
+)
+
+// Strategies for choosing the previous release (values of the --previous flag).
+const (
+	// PreviousPublished picks the release published immediately before.
+	PreviousPublished = "published"
+	// PreviousSemver picks the highest semantic version below the tag.
+	PreviousSemver = "semver"
+	// PreviousTopology picks the nearest release tag among the ancestors of the tag.
+	PreviousTopology = "topology"
+	// PreviousSameLine picks the release published immediately before the tag among those
+	// with the same major and minor version, falling back to semver for the first release of a line.
+	PreviousSameLine = "same-line"
+)
+
+// Strategies lists all previous-release strategies in display order.
+var Strategies = []string{PreviousPublished, PreviousSemver, PreviousTopology, PreviousSameLine}
+
+// Prerelease policies (values of the --prereleases flag): whether prereleases can be
+// chosen as the previous release.
+const (
+	// PrereleasesAuto lets prereleases follow each other (rc2 after rc1), while a stable
+	// release spans from the previous stable one.
+	PrereleasesAuto = "auto"
+	// PrereleasesInclude treats prereleases like any other release.
+	PrereleasesInclude = "include"
+	// PrereleasesSkip never picks a prerelease as the previous release.
+	PrereleasesSkip = "skip"
+)
+
+// PrereleasePolicies lists all prerelease policies in display order.
+var PrereleasePolicies = []string{PrereleasesAuto, PrereleasesInclude, PrereleasesSkip}
+
+// PreviousOptions configure FindPrevious.
+type PreviousOptions struct {
+	// Strategy is one of Strategies.
+	Strategy string
+	// Prereleases is one of PrereleasePolicies.
+	Prereleases string
+	// TagPrefix restricts candidates to tags with the prefix (a monorepo component),
+	// and is stripped before parsing versions.
+	TagPrefix string
+	// TagValidator, if set, filters releases to those with valid git tags.
+	TagValidator func(string) bool
+}
+
+// FindPrevious finds the release preceding tag. Drafts are never chosen, and prereleases
+// only as allowed by the prerelease policy. Returns nil (without error) if there is no
+// previous release.
+func FindPrevious(ctx context.Context, releases []Release, tag string, opts PreviousOptions) (*Release, error) {
+	i := slices.IndexFunc(releases, func(r Release) bool { return r.TagName == tag })
+	if i == -1 {
+		return nil, errors.Runtime("release "+tag+" not found", nil)
+	}
+
+	candidates, err := filterCandidates(releases, releases[i], opts)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Strategy {
+	case PreviousPublished:
+		return FindPreviousRelease(candidates, tag, opts.TagValidator)
+	case PreviousSemver:
+		return findPreviousSemver(candidates, tag, opts)
+	case PreviousSameLine:
+		return findPreviousSameLine(candidates, tag, opts)
+	case PreviousTopology:
+		return findPreviousTopology(ctx, candidates, tag, opts.TagValidator)
+	default:
+		return nil, errors.Config("unknown previous-release strategy " + opts.Strategy +
+			" (supported: " + strings.Join(Strategies, ", ") + ")")
+	}
+}
+
+// filterCandidates returns the target and the releases that may precede it: no drafts,
+// only tags with the prefix, and prereleases only as allowed by the policy.
+func filterCandidates(releases []Release, target Release, opts PreviousOptions) ([]Release, error) {
+	var allowPrereleases bool
+
+	switch opts.Prereleases {
+	case PrereleasesAuto:
+		allowPrereleases = isPrerelease(target, opts.TagPrefix)
+	case PrereleasesInclude:
+		allowPrereleases = true
+	case PrereleasesSkip:
+	default:
+		return nil, errors.Config("unknown prerelease policy " + opts.Prereleases +
+			" (supported: " + strings.Join(PrereleasePolicies, ", ") + ")")
+	}
+
+	var candidates []Release
+
+	for _, r := range releases {
+		if r.TagName == target.TagName {
+			candidates = append(candidates, r)
+
+			continue
+		}
+
+		if r.IsDraft || !strings.HasPrefix(r.TagName, opts.TagPrefix) {
+			continue
+		}
+
+		if allowPrereleases || !isPrerelease(r, opts.TagPrefix) {
+			candidates = append(candidates, r)
+		}
+	}
+
+	return candidates, nil
+}
+
+// isPrerelease reports whether a release is marked as a prerelease on the forge or
+// its tag, without the prefix, is a semantic version with prerelease identifiers.
+func isPrerelease(r Release, tagPrefix string) bool {
+	if r.IsPrerelease {
+		return true
+	}
+
+	v, ok := parseVersion(r.TagName, tagPrefix)
+
+	return ok && v.IsPrerelease()
+}
+
+// parseVersion parses the semantic version of a tag after its prefix, e.g. "1.2.0" of "api/v1.2.0".
+func parseVersion(tag, tagPrefix string) (semver.Version, bool) {
+	if !strings.HasPrefix(tag, tagPrefix) {
+		return semver.Version{}, false
+	}
+
+	return semver.Parse(strings.TrimPrefix(tag, tagPrefix))
+}
+
+// findPreviousSemver returns the release with the highest semantic version below tag.
+// Tags that are not semantic versions are ignored.
+func findPreviousSemver(releases []Release, tag string, opts PreviousOptions) (*Release, error) {
+	target, ok := parseVersion(tag, opts.TagPrefix)
+	if !ok {
+		return nil, errors.Config("tag " + tag + " is not a semantic version; use another --previous strategy")
+	}
+
+	var (
+		best        *Release
+		bestVersion semver.Version
+	)
+
+	for i, r := range releases {
+		v, ok := parseVersion(r.TagName, opts.TagPrefix)
+		if !ok || semver.Compare(v, target) >= 0 {
+			continue
+		}
+
+		if best != nil && semver.Compare(v, bestVersion) <= 0 {
+			continue
+		}
+
+		if opts.TagValidator != nil && !opts.TagValidator(r.TagName) {
+			continue
+		}
+
+		best, bestVersion = &releases[i], v
+	}
+
+	if best == nil {
+		return nil, nil
+	}
+
+	found := *best
+
+	return &found, nil
+}
+
+// findPreviousSameLine returns the release published immediately before tag on its
+// major.minor maintenance line, so a backported v1.4.3 follows v1.4.2 even if v2.0.0
+// was published in between. The first release of a line falls back to findPreviousSemver.
+func findPreviousSameLine(releases []Release, tag string, opts PreviousOptions) (*Release, error) {
+	target, ok := parseVersion(tag, opts.TagPrefix)
+	if !ok {
+		return nil, errors.Config("tag " + tag + " is not a semantic version; use another --previous strategy")
+	}
+
+	line := slices.DeleteFunc(slices.Clone(releases), func(r Release) bool {
+		v, ok := parseVersion(r.TagName, opts.TagPrefix)
+
+		return !ok || v.Major != target.Major || v.Minor != target.Minor
+	})
+
+	prev, err := FindPreviousRelease(line, tag, opts.TagValidator)
+	if err != nil || prev != nil {
+		return prev, err
+	}
+
+	return findPreviousSemver(releases, tag, opts)
+}
+
+// findPreviousTopology returns the release whose tag is the nearest ancestor of tag in the commit graph.
+func findPreviousTopology(
+	ctx context.Context, releases []Release, tag string, tagValidator func(string) bool,
+) (*Release, error) {
+	var candidates []string
+
+	for _, r := range releases {
+		if r.TagName != tag && (tagValidator == nil || tagValidator(r.TagName)) {
+			candidates = append(candidates, r.TagName)
+		}
+	}
+
+	nearest, err := git.NearestTag(ctx, tag, candidates)
+	if err != nil || nearest == "" {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(releases, func(r Release) bool { return r.TagName == nearest })
+	found := releases[i]
+
+	return &found, nil
+}
+
+// FilterReleases returns the releases whose tags match a glob pattern, by the rules of
+// glob.Regexp: "*" does not match "/", so "v*" leaves out "api/v1.0.0" but "**v*" does not.
+// An empty pattern matches all tags.
+func FilterReleases(releases []Release, pattern string) []Release {
+	if pattern == "" {
+		return releases
+	}
+
+	re := glob.Regexp(pattern)
+
+	var filtered []Release
+
+	for _, r := range releases {
+		if re.MatchString(r.TagName) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,262 @@
+package forge
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindPrevious_semver(t *testing.T) {
+	// Publish dates deliberately disagree with version order (a backported patch)
+	releases := []Release{
+		{TagName: "v1.0.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0.0", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v1.0.1", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "nightly", PublishedAt: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.1.0", PublishedAt: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"v2.1.0", "v2.0.0"},
+		{"v2.0.0", "v1.0.1"},
+		{"v1.0.1", "v1.0.0"},
+		{"v1.0.0", ""},
+	}
+
+	for _, tt := range tests {
+		opts := PreviousOptions{Strategy: PreviousSemver, Prereleases: PrereleasesAuto}
+		got, err := FindPrevious(t.Context(), releases, tt.tag, opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.tag, err)
+		}
+
+		if name := tagName(got); name != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.tag, name, tt.want)
+		}
+	}
+}
+
+func TestFindPrevious_semver_skips_invalid_tags(t *testing.T) {
+	releases := []Release{{TagName: "v1.0.0"}, {TagName: "v1.1.0"}, {TagName: "v1.2.0"}}
+
+	got, err := FindPrevious(t.Context(), releases, "v1.2.0", PreviousOptions{
+		Strategy:     PreviousSemver,
+		Prereleases:  PrereleasesAuto,
+		TagValidator: func(tag string) bool { return tag != "v1.1.0" },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if name := tagName(got); name != "v1.0.0" {
+		t.Errorf("got %q, want %q", name, "v1.0.0")
+	}
+}
+
+func TestFindPrevious_semver_not_a_version(t *testing.T) {
+	releases := []Release{{TagName: "v1.0.0"}, {TagName: "nightly"}}
+
+	opts := PreviousOptions{Strategy: PreviousSemver, Prereleases: PrereleasesAuto}
+	if _, err := FindPrevious(t.Context(), releases, "nightly", opts); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestFindPrevious_tag_not_found(t *testing.T) {
+	for _, strategy := range Strategies {
+		opts := PreviousOptions{Strategy: strategy, Prereleases: PrereleasesAuto}
+		if _, err := FindPrevious(t.Context(), []Release{{TagName: "v1.0.0"}}, "v2.0.0", opts); err == nil {
+			t.Errorf("%s: expected error, got nil", strategy)
+		}
+	}
+}
+
+func TestFindPrevious_unknown_strategy(t *testing.T) {
+	opts := PreviousOptions{Strategy: "random", Prereleases: PrereleasesAuto}
+	if _, err := FindPrevious(t.Context(), []Release{{TagName: "v1.0.0"}}, "v1.0.0", opts); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestFindPrevious_same_line(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.4.2", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0.0", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v1.4.3", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.1.0", PublishedAt: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0.1", PublishedAt: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"v1.4.3", "v1.4.2"},
+		{"v2.0.1", "v2.0.0"},
+		// First release of a line: the highest version below
+		{"v2.1.0", "v2.0.1"},
+		{"v2.0.0", "v1.4.3"},
+		{"v1.4.2", ""},
+	}
+
+	for _, tt := range tests {
+		opts := PreviousOptions{Strategy: PreviousSameLine, Prereleases: PrereleasesAuto}
+		got, err := FindPrevious(t.Context(), releases, tt.tag, opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.tag, err)
+		}
+
+		if name := tagName(got); name != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.tag, name, tt.want)
+		}
+	}
+}
+
+func TestFindPrevious_prereleases(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.9.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0.0-rc.1", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v2.0.0-rc.2", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "beta", PublishedAt: time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC), IsPrerelease: true},
+		{TagName: "v2.0.0", PublishedAt: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		tag      string
+		strategy string
+		policy   string
+		want     string
+	}{
+		{"v2.0.0", PreviousPublished, PrereleasesAuto, "v1.9.0"},
+		{"v2.0.0", PreviousSemver, PrereleasesAuto, "v1.9.0"},
+		{"v2.0.0-rc.2", PreviousPublished, PrereleasesAuto, "v2.0.0-rc.1"},
+		{"v2.0.0", PreviousPublished, PrereleasesInclude, "beta"},
+		{"v2.0.0", PreviousSemver, PrereleasesInclude, "v2.0.0-rc.2"},
+		{"v2.0.0-rc.2", PreviousPublished, PrereleasesSkip, "v1.9.0"},
+	}
+
+	for _, tt := range tests {
+		opts := PreviousOptions{Strategy: tt.strategy, Prereleases: tt.policy}
+		got, err := FindPrevious(t.Context(), releases, tt.tag, opts)
+		if err != nil {
+			t.Fatalf("%s/%s/%s: unexpected error: %v", tt.tag, tt.strategy, tt.policy, err)
+		}
+
+		if name := tagName(got); name != tt.want {
+			t.Errorf("%s/%s/%s: got %q, want %q", tt.tag, tt.strategy, tt.policy, name, tt.want)
+		}
+	}
+}
+
+func TestFindPrevious_unknown_prerelease_policy(t *testing.T) {
+	opts := PreviousOptions{Strategy: PreviousSemver, Prereleases: "sometimes"}
+	if _, err := FindPrevious(t.Context(), []Release{{TagName: "v1.0.0"}}, "v1.0.0", opts); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestFindPrevious_drafts(t *testing.T) {
+	releases := []Release{
+		{TagName: "v1.0.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v1.1.0", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "v1.2.0", IsDraft: true},
+		{TagName: "v1.3.0", IsDraft: true},
+	}
+
+	for _, strategy := range []string{PreviousPublished, PreviousSemver, PreviousSameLine} {
+		opts := PreviousOptions{Strategy: strategy, Prereleases: PrereleasesAuto}
+		got, err := FindPrevious(t.Context(), releases, "v1.3.0", opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", strategy, err)
+		}
+
+		if name := tagName(got); name != "v1.1.0" {
+			t.Errorf("%s: got %q, want %q", strategy, name, "v1.1.0")
+		}
+	}
+}
+
+func TestFindPrevious_tag_prefix(t *testing.T) {
+	releases := []Release{
+		{TagName: "api/v1.0.0", PublishedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "web/v3.0.0", PublishedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "api/v1.1.0-rc.1", PublishedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{TagName: "api/v1.1.0", PublishedAt: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, strategy := range []string{PreviousPublished, PreviousSemver, PreviousSameLine} {
+		opts := PreviousOptions{Strategy: strategy, Prereleases: PrereleasesAuto, TagPrefix: "api/"}
+
+		got, err := FindPrevious(t.Context(), releases, "api/v1.1.0", opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", strategy, err)
+		}
+
+		if name := tagName(got); name != "api/v1.0.0" {
+			t.Errorf("%s: got %q, want %q", strategy, name, "api/v1.0.0")
+		}
+	}
+}
+
+func TestCompareRange(t *testing.T) {
+	if got := compareRange("api/v1.0.0", "api/v1.1.0+build 1"); got != "api/v1.0.0...api/v1.1.0+build%201" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFilterReleases(t *testing.T) {
+	releases := []Release{{TagName: "v1.0.0"}, {TagName: "api/v1.0.0"}, {TagName: "v2.0.0-rc.1"}, {TagName: "nightly"}}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", []string{"v1.0.0", "api/v1.0.0", "v2.0.0-rc.1", "nightly"}},
+		{"v*", []string{"v1.0.0", "v2.0.0-rc.1"}},
+		{"api/*", []string{"api/v1.0.0"}},
+		{"*v1.0.?", []string{"v1.0.0"}},
+		{"**v1.0.?", []string{"v1.0.0", "api/v1.0.0"}},
+		{"v1.0.0+x", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, r := range FilterReleases(releases, tt.pattern) {
+			got = append(got, r.TagName)
+		}
+
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("FilterReleases(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestGitTagsCompareURL(t *testing.T) {
+	tests := []struct {
+		remote *remoteRepo
+		want   string
+	}{
+		{&remoteRepo{Host: "github.com"}, "https://example.com/me/app/compare/v1...v2"},
+		{&remoteRepo{Host: "gitlab.com"}, "https://example.com/me/app/-/compare/v1...v2"},
+		{&remoteRepo{Host: "git.example.com"}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		got := newGitTags(tt.remote).CompareURL(&RepoInfo{WebURL: "https://example.com/me/app"}, "v1", "v2")
+		if got != tt.want {
+			t.Errorf("CompareURL(%+v) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func tagName(r *Release) string {
+	if r == nil {
+		return ""
+	}
+
+	return r.TagName
+}
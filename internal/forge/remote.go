@@ -0,0 +1,93 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const defaultHost = "github.com"
+
+// remoteRepo identifies a repository on a forge host.
+type remoteRepo struct {
+	// Host is the host name, including a port for HTTP(S) remotes.
+	Host string
+	// BaseURL is the web root of the forge, e.g. https://gitlab.example.com.
+	BaseURL string
+	// Owner is the user, organization or (possibly nested) group.
+	Owner string
+	Name  string
+}
+
+// Path returns the repository path on the host, e.g. "group/subgroup/repo".
+func (r *remoteRepo) Path() string {
+	return r.Owner + "/" + r.Name
+}
+
+// WebURL returns the repository web page.
+func (r *remoteRepo) WebURL() string {
+	return r.BaseURL + "/" + r.Path()
+}
+
+// parseRemoteURL extracts host, owner and repository name from a git remote URL.
+// Supported forms:
+//   - https://github.com/owner/repo(.git)
+//   - ssh://git@github.com/owner/repo(.git)
+//   - git@github.com:owner/repo(.git)
+//
+// The owner may span several path segments (GitLab subgroups).
+func parseRemoteURL(remote string) (*remoteRepo, error) {
+	remote = strings.TrimSpace(remote)
+
+	var host, baseURL, path string
+
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return nil, err
+		}
+
+		host, path = u.Hostname(), u.Path
+		baseURL = "https://" + host
+
+		// HTTP(S) remotes share host and port with the web UI; SSH ports do not.
+		if u.Scheme == "http" || u.Scheme == "https" {
+			host = u.Host
+			baseURL = u.Scheme + "://" + host
+		}
+	} else {
+		// scp-like syntax: [user@]host:path
+		at := strings.Index(remote, "@")
+		colon := strings.Index(remote, ":")
+
+		if colon == -1 || colon < at {
+			return nil, fmt.Errorf("unrecognized remote URL %q", remote)
+		}
+
+		host, path = remote[at+1:colon], remote[colon+1:]
+		baseURL = "https://" + host
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+
+	slash := strings.LastIndex(path, "/")
+	if host == "" || slash <= 0 || slash == len(path)-1 {
+		return nil, fmt.Errorf("unrecognized remote URL %q", remote)
+	}
+
+	return &remoteRepo{Host: host, BaseURL: baseURL, Owner: path[:slash], Name: path[slash+1:]}, nil
+}
+
+// parseRepoSlug parses "owner/repo" or "host/owner/repo" (the GH_REPO format).
+func parseRepoSlug(slug string) (*remoteRepo, error) {
+	parts := strings.Split(strings.TrimSuffix(slug, ".git"), "/")
+
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return &remoteRepo{Host: defaultHost, BaseURL: "https://" + defaultHost, Owner: parts[0], Name: parts[1]}, nil
+	case len(parts) == 3 && parts[0] != "" && parts[1] != "" && parts[2] != "":
+		return &remoteRepo{Host: parts[0], BaseURL: "https://" + parts[0], Owner: parts[1], Name: parts[2]}, nil
+	default:
+		return nil, fmt.Errorf("invalid repository %q, expected owner/repo", slug)
+	}
+}
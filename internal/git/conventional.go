@@ -0,0 +1,86 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Conventional is a commit message header parsed according to Conventional Commits
+// (https://www.conventionalcommits.org), e.g. "feat(api)!: drop v1 endpoints".
+type Conventional struct {
+	// Type is the lower-cased type, e.g. "feat" or "fix". It is empty for a subject
+	// that does not follow the convention, in a message with a BREAKING CHANGE footer.
+	Type  string
+	Scope string
+	// Description is the rest of the subject after the colon.
+	Description string
+	// Breaking is set by a "!" after the type or scope, or by a BREAKING CHANGE footer.
+	Breaking bool
+	// BreakingNote is the text of the BREAKING CHANGE footer, if any.
+	BreakingNote string
+}
+
+var (
+	conventionalHeader = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?: +(\S.*)$`)
+	breakingFooter     = regexp.MustCompile(`^BREAKING[ -]CHANGE: *(.*)$`)
+	// footerToken starts a git trailer style footer, e.g. "Refs: #123" or "Fixes #45".
+	footerToken = regexp.MustCompile(`^(?:[A-Za-z-]+: |[A-Za-z-]+ #)`)
+)
+
+// ParseConventional parses the header and footers of a commit message. A BREAKING CHANGE
+// footer counts whatever the subject: a subject that does not follow Conventional Commits
+// then becomes the Description, with no Type. Returns nil for a message with neither.
+func ParseConventional(message string) *Conventional {
+	subject, body, _ := strings.Cut(message, "\n")
+	subject = strings.TrimSpace(subject)
+
+	c := &Conventional{Description: subject}
+
+	if m := conventionalHeader.FindStringSubmatch(subject); m != nil {
+		c.Type = strings.ToLower(m[1])
+		c.Scope = strings.TrimSpace(m[2])
+		c.Description = strings.TrimSpace(m[4])
+		c.Breaking = m[3] == "!"
+	}
+
+	if note, ok := breakingNote(body); ok {
+		c.Breaking = true
+		c.BreakingNote = note
+	}
+
+	if c.Type == "" && !c.Breaking {
+		return nil
+	}
+
+	return c
+}
+
+// breakingNote finds a BREAKING CHANGE footer in the message body. The note continues
+// on the following lines until a blank line or the next footer.
+func breakingNote(body string) (string, bool) {
+	var (
+		note  []string
+		found bool
+	)
+
+	for line := range strings.SplitSeq(body, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+
+		if !found {
+			if m := breakingFooter.FindStringSubmatch(line); m != nil {
+				found = true
+				note = append(note, m[1])
+			}
+
+			continue
+		}
+
+		if line == "" || footerToken.MatchString(line) {
+			break
+		}
+
+		note = append(note, strings.TrimSpace(line))
+	}
+
+	return strings.TrimSpace(strings.Join(note, " ")), found
+}
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		message string
+		want    *Conventional
+	}{
+		{"feat: add export", &Conventional{Type: "feat", Description: "add export"}},
+		{"fix(api): handle empty body", &Conventional{Type: "fix", Scope: "api", Description: "handle empty body"}},
+		{"Feat(cli)!: rename --out", &Conventional{Type: "feat", Scope: "cli", Description: "rename --out", Breaking: true}},
+		{
+			"refactor!: drop Go 1.22\n\nBody text.\n\nBREAKING CHANGE: Go 1.23 is\nnow required\nRefs: #12",
+			&Conventional{
+				Type: "refactor", Description: "drop Go 1.22", Breaking: true,
+				BreakingNote: "Go 1.23 is now required",
+			},
+		},
+		{
+			"chore(deps): bump x\n\nBREAKING-CHANGE: x v2 changes the config format",
+			&Conventional{
+				Type: "chore", Scope: "deps", Description: "bump x", Breaking: true,
+				BreakingNote: "x v2 changes the config format",
+			},
+		},
+		{
+			"Remove the v1 API\n\nBREAKING CHANGE: use /v2 instead",
+			&Conventional{Description: "Remove the v1 API", Breaking: true, BreakingNote: "use /v2 instead"},
+		},
+		{"Merge branch 'feature'", nil},
+		{"Update README.md", nil},
+		{"feat:missing space", nil},
+		{"feat(api: unbalanced", nil},
+	}
+
+	for _, tt := range tests {
+		if got := ParseConventional(tt.message); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseConventional(%q) = %+v, want %+v", tt.message, got, tt.want)
+		}
+	}
+}
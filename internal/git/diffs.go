@@ -0,0 +1,348 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// Diff modes (values of the --diffs flag): how much of the patches of the commits goes
+// into the prompt.
+const (
+	// DiffsNone embeds no patches; the model runs git show when it needs one.
+	DiffsNone = "none"
+	// DiffsSmall embeds the patches of files with few changes and leaves out the others.
+	DiffsSmall = "small"
+	// DiffsAll embeds the patches of all files, cutting long ones short.
+	DiffsAll = "all"
+)
+
+// DiffModes lists all diff modes in display order.
+var DiffModes = []string{DiffsNone, DiffsSmall, DiffsAll}
+
+// diffLimits caps the patches embedded in each mode, in bytes: File for the patch of
+// one file in one commit, Total for all patches of a release.
+var diffLimits = map[string]struct{ File, Total int }{
+	DiffsSmall: {File: 2 << 10, Total: 64 << 10},
+	DiffsAll:   {File: 16 << 10, Total: 512 << 10},
+}
+
+// vendoredDirs are directories of third-party code, whose changes say little about the release.
+var vendoredDirs = []string{"vendor", "node_modules", "third_party", "bower_components"}
+
+// generatedFiles are names of files written by tools, mostly lock files.
+var generatedFiles = []string{
+	"go.sum", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+	"Cargo.lock", "Gemfile.lock", "composer.lock", "poetry.lock", "uv.lock", "Pipfile.lock",
+}
+
+// generatedSuffixes are name endings of generated code.
+var generatedSuffixes = []string{
+	".pb.go", "_pb2.py", ".pb.cc", ".pb.h", "_generated.go", ".gen.go", ".g.dart", ".min.js", ".min.css", ".map",
+}
+
+// DiffVariant describes a diff mode with its caps, e.g. "small:2048:65536", or returns ""
+// for a mode that embeds no patches. Summaries written from different variants differ.
+func DiffVariant(mode string) string {
+	limits, ok := diffLimits[mode]
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d:%d", mode, limits.File, limits.Total)
+}
+
+// DiffOptions configure AddDiffs.
+type DiffOptions struct {
+	// Mode is one of DiffModes; "" is DiffsNone.
+	Mode string
+	// Paths limit the patches to the files within these pathspec globs, like the commits.
+	Paths []string
+	// FirstParent embeds the patches of merge commits against their first parent, which
+	// then stand for the merged branches. Otherwise merges get no patch.
+	FirstParent bool
+}
+
+// CheckDiffMode returns a configuration error for an unknown diff mode.
+func CheckDiffMode(mode string) error {
+	if mode != "" && !slices.Contains(DiffModes, mode) {
+		return errors.Config("unknown diff mode " + mode + " (supported: " + strings.Join(DiffModes, ", ") + ")")
+	}
+
+	return nil
+}
+
+// AddDiffs sets the Diff of the commits, newest first, to their patches as allowed by the
+// mode: binary, vendored and generated files are left out, and the patches of the other
+// files are capped per file and in total. Once the total is reached, older commits get no patch.
+func AddDiffs(ctx context.Context, commits []Commit, opts DiffOptions) error {
+	if err := CheckDiffMode(opts.Mode); err != nil {
+		return err
+	}
+
+	limits, ok := diffLimits[opts.Mode]
+	if !ok {
+		return nil
+	}
+
+	left := limits.Total
+
+	return commitPatches(ctx, commits, opts, func(c *Commit, patch string) bool {
+		c.Diff = capPatch(patch, opts.Mode, limits.File, &left)
+
+		return left > 0
+	})
+}
+
+// commitPatches calls fn with the patch of each commit against its first parent, or
+// against the empty tree for a root commit, in order, until fn returns false. Merges get
+// no patch unless opts.FirstParent is set. One git process writes all patches, and is
+// stopped once fn has had enough.
+func commitPatches(ctx context.Context, commits []Commit, opts DiffOptions, fn func(*Commit, string) bool) error {
+	var (
+		input   strings.Builder
+		indexOf = map[string]int{}
+	)
+
+	for i, c := range commits {
+		switch {
+		case len(c.Parents) > 1 && !opts.FirstParent:
+			continue
+		case len(c.Parents) > 1:
+			// Against the first parent only; git diff-tree gives no patch for a merge otherwise
+			fmt.Fprintf(&input, "%s %s\n", c.Hash, c.Parents[0])
+		default:
+			fmt.Fprintf(&input, "%s\n", c.Hash)
+		}
+
+		indexOf[c.Hash] = i
+	}
+
+	if len(indexOf) == 0 {
+		return nil
+	}
+
+	args := []string{"diff-tree", "--stdin", "-p", "-r", "-M", "--no-color", "--root"}
+
+	if len(opts.Paths) > 0 {
+		args = append(args, "--")
+		for _, p := range opts.Paths {
+			args = append(args, ":(glob)"+p)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := command(ctx, args...)
+	cmd.Stdin = strings.NewReader(input.String())
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+
+	if err != nil {
+		return errors.Runtime("failed to get the diffs of the commits", err)
+	}
+
+	// Each patch follows a line with the hash of its commit; commits without changes
+	// in the paths have none
+	var (
+		current = -1
+		patch   strings.Builder
+		done    bool
+	)
+
+	flush := func() {
+		if current >= 0 && !done {
+			done = !fn(&commits[current], patch.String())
+		}
+
+		patch.Reset()
+	}
+
+	r := bufio.NewReader(stdout)
+
+	for !done {
+		line, readErr := r.ReadString('\n')
+
+		if i, ok := indexOf[strings.TrimSuffix(line, "\n")]; ok {
+			flush()
+			current = i
+		} else {
+			patch.WriteString(line)
+		}
+
+		if readErr != nil {
+			flush()
+
+			break
+		}
+	}
+
+	if done {
+		// The rest of the patches are not needed
+		cancel()
+		_ = cmd.Wait()
+
+		return nil
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return errors.Runtime("failed to get the diffs of the commits",
+			fmt.Errorf("%s", strings.TrimSpace(stderr.String())))
+	}
+
+	return nil
+}
+
+// capPatch keeps the file patches that fit in the per-file limit and in what is left of
+// the total, and notes the files it leaves out or cuts.
+func capPatch(patch, mode string, fileLimit int, left *int) string {
+	var b strings.Builder
+
+	for _, file := range splitPatch(patch) {
+		name := patchPath(file)
+
+		if reason := skipReason(name, file); reason != "" {
+			fmt.Fprintf(&b, "(%s: %s, left out)\n", name, reason)
+
+			continue
+		}
+
+		if len(file) > fileLimit {
+			if mode == DiffsSmall {
+				fmt.Fprintf(&b, "(%s: %d changed lines, left out)\n", name, changedLines(file))
+
+				continue
+			}
+
+			file = cutPatch(file, fileLimit)
+		}
+
+		if len(file) > *left {
+			*left = 0
+
+			fmt.Fprintf(&b, "(%s and later files: over the size limit for diffs, left out)\n", name)
+
+			break
+		}
+
+		*left -= len(file)
+
+		b.WriteString(file)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// splitPatch splits a patch into the patches of its files.
+func splitPatch(patch string) []string {
+	var files []string
+
+	for _, part := range strings.SplitAfter(patch, "\ndiff --git ") {
+		if part = strings.TrimSuffix(part, "diff --git "); part == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(part, "diff --git ") {
+			part = "diff --git " + part
+		}
+
+		files = append(files, part)
+	}
+
+	return files
+}
+
+// patchPath returns the path of the file of a patch after the change. Git quotes paths
+// with special characters, such as "b/\303\251t\303\251.go" for été.go, and escapes them.
+func patchPath(file string) string {
+	header, _, _ := strings.Cut(file, "\n")
+
+	if i := strings.LastIndex(header, ` "b/`); i >= 0 && strings.HasSuffix(header, `"`) {
+		if name, err := strconv.Unquote(header[i+1:]); err == nil {
+			return strings.TrimPrefix(name, "b/")
+		}
+	}
+
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return header[i+3:]
+	}
+
+	return strings.TrimPrefix(header, "diff --git ")
+}
+
+// skipReason tells why the patch of a file is not worth embedding, or returns "".
+func skipReason(name, file string) string {
+	switch {
+	case strings.Contains(file, "\nBinary files ") || strings.Contains(file, "\nGIT binary patch"):
+		return "binary"
+	case slices.ContainsFunc(strings.Split(path.Dir(name), "/"), func(dir string) bool {
+		return slices.Contains(vendoredDirs, dir)
+	}):
+		return "vendored"
+	case slices.Contains(generatedFiles, path.Base(name)),
+		slices.ContainsFunc(generatedSuffixes, func(s string) bool { return strings.HasSuffix(name, s) }),
+		isGeneratedCode(file):
+		return "generated"
+	}
+
+	return ""
+}
+
+// isGeneratedCode reports whether a patch adds the header of generated code within its first lines,
+// such as "// Code generated by protoc-gen-go. DO NOT EDIT." or "@generated".
+func isGeneratedCode(file string) bool {
+	lines := strings.SplitN(file, "\n", 20)
+
+	return slices.ContainsFunc(lines, func(line string) bool {
+		return strings.HasPrefix(line, "+") &&
+			(strings.Contains(line, "DO NOT EDIT") || strings.Contains(line, "@generated"))
+	})
+}
+
+// cutPatch cuts a file patch at a line boundary to at most limit bytes. A first line over
+// the limit, such as one of minified code, is cut inside instead.
+func cutPatch(file string, limit int) string {
+	cut := strings.LastIndexByte(file[:limit], '\n') + 1
+	if cut > 0 {
+		return file[:cut] + fmt.Sprintf("... %d more lines\n", strings.Count(file[cut:], "\n"))
+	}
+
+	cut = limit
+	for cut > 0 && !utf8.RuneStart(file[cut]) {
+		cut--
+	}
+
+	// The rest of the cut line is not counted
+	rest := max(strings.Count(file[cut:], "\n")-1, 0)
+
+	return file[:cut] + fmt.Sprintf("...\n... %d more lines\n", rest)
+}
+
+// changedLines counts the added and removed lines of a file patch.
+func changedLines(file string) int {
+	n := 0
+
+	for line := range strings.SplitSeq(file, "\n") {
+		if (strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++ ")) ||
+			(strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "--- ")) {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,274 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func filePatch(name string, lines int) string {
+	var b strings.Builder
+
+	b.WriteString("diff --git a/" + name + " b/" + name + "\n")
+	b.WriteString("--- a/" + name + "\n+++ b/" + name + "\n@@ -1 +1 @@\n")
+
+	for range lines {
+		b.WriteString("+added line\n")
+	}
+
+	return b.String()
+}
+
+func TestSplitPatch(t *testing.T) {
+	files := splitPatch(filePatch("a.go", 1) + filePatch("dir/b.go", 2))
+
+	if len(files) != 2 || files[0] != filePatch("a.go", 1) || files[1] != filePatch("dir/b.go", 2) {
+		t.Errorf("got %q", files)
+	}
+
+	if patchPath(files[1]) != "dir/b.go" {
+		t.Errorf("patchPath = %q, want dir/b.go", patchPath(files[1]))
+	}
+}
+
+func TestPatchPath_quoted(t *testing.T) {
+	file := "diff --git \"a/vendor/caf\\303\\251/x.go\" \"b/vendor/caf\\303\\251/x.go\"\n+x\n"
+
+	if got := patchPath(file); got != "vendor/café/x.go" {
+		t.Errorf("patchPath = %q, want vendor/café/x.go", got)
+	}
+
+	if got := skipReason(patchPath(file), file); got != "vendored" {
+		t.Errorf("skipReason = %q, want vendored", got)
+	}
+}
+
+func TestCutPatch_long_first_line(t *testing.T) {
+	file := "diff --git a/app.min.css b/app.min.css " + strings.Repeat("é", 100) + "\n+x\n+y\n"
+
+	got := cutPatch(file, 60)
+	if !strings.HasPrefix(got, "diff --git a/app.min.css") || !strings.HasSuffix(got, "...\n... 2 more lines\n") {
+		t.Errorf("got %q", got)
+	}
+
+	if len(got) > 60+len("...\n... 2 more lines\n") || !utf8.ValidString(got) {
+		t.Errorf("cut at the wrong place: %q", got)
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"main.go", filePatch("main.go", 1), ""},
+		{"logo.png", "diff --git a/logo.png b/logo.png\nBinary files /dev/null and b/logo.png differ\n", "binary"},
+		{"vendor/github.com/x/y.go", filePatch("vendor/github.com/x/y.go", 1), "vendored"},
+		{"web/node_modules/x/index.js", filePatch("web/node_modules/x/index.js", 1), "vendored"},
+		{"go.sum", filePatch("go.sum", 1), "generated"},
+		{"api/api.pb.go", filePatch("api/api.pb.go", 1), "generated"},
+		{"gen.go", "diff --git a/gen.go b/gen.go\n+// Code generated by stringer. DO NOT EDIT.\n", "generated"},
+		{"vendors.go", filePatch("vendors.go", 1), ""},
+	}
+
+	for _, tt := range tests {
+		if got := skipReason(tt.name, tt.patch); got != tt.want {
+			t.Errorf("skipReason(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCapPatch_small_leaves_out_large_files(t *testing.T) {
+	left := 10_000
+	patch := filePatch("small.go", 2) + filePatch("large.go", 500) + filePatch("go.sum", 1)
+
+	got := capPatch(patch, DiffsSmall, 1000, &left)
+
+	if !strings.Contains(got, "+++ b/small.go") || strings.Contains(got, "+++ b/large.go") {
+		t.Errorf("got:\n%s", got)
+	}
+
+	for _, note := range []string{"(large.go: 500 changed lines, left out)", "(go.sum: generated, left out)"} {
+		if !strings.Contains(got, note) {
+			t.Errorf("missing %q in:\n%s", note, got)
+		}
+	}
+
+	if left != 10_000-len(filePatch("small.go", 2)) {
+		t.Errorf("left = %d", left)
+	}
+}
+
+func TestCapPatch_all_cuts_large_files(t *testing.T) {
+	left := 10_000
+
+	got := capPatch(filePatch("large.go", 500), DiffsAll, 1000, &left)
+
+	if !strings.Contains(got, "+++ b/large.go") || !strings.Contains(got, "more lines") || len(got) > 1100 {
+		t.Errorf("got %d bytes:\n%s", len(got), got)
+	}
+}
+
+func TestCapPatch_total(t *testing.T) {
+	left := 150
+
+	got := capPatch(filePatch("a.go", 2)+filePatch("b.go", 10), DiffsAll, 1000, &left)
+
+	if !strings.Contains(got, "+++ b/a.go") || !strings.Contains(got, "(b.go and later files: over the size limit") {
+		t.Errorf("got:\n%s", got)
+	}
+
+	if left != 0 {
+		t.Errorf("left = %d, want 0", left)
+	}
+}
+
+func TestAddDiffs(t *testing.T) {
+	t.Chdir(t.TempDir())
+	runGit(t, "init", "-q", "-b", "main")
+
+	commit := func(path, content, msg string) {
+		t.Helper()
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		runGit(t, "add", "-A")
+		runGit(t, "commit", "-qm", msg)
+	}
+
+	commit("main.go", "package main\n", "initial")
+	runGit(t, "tag", "v1.0.0")
+	commit("main.go", "package main\n\nfunc main() {}\n", "add main")
+	commit("vendor/lib/lib.go", "package lib\n", "vendor lib")
+
+	commits, _, err := GetCommitDetailsFromRoot(t.Context(), "HEAD", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := AddDiffs(t.Context(), commits, DiffOptions{Mode: DiffsAll}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Newest first: vendor lib, add main, initial (a root commit)
+	if got := commits[0].Diff; got != "(vendor/lib/lib.go: vendored, left out)" {
+		t.Errorf("vendored commit diff = %q", got)
+	}
+
+	if got := commits[1].Diff; !strings.Contains(got, "+func main() {}") {
+		t.Errorf("missing patch in %q", got)
+	}
+
+	if got := commits[2].Diff; !strings.Contains(got, "+package main") {
+		t.Errorf("missing patch of the root commit in %q", got)
+	}
+
+	if !strings.Contains(FormatCommits(commits[1:2]), "\nDiff:\n") {
+		t.Error("missing diff in the formatted commits")
+	}
+}
+
+func TestAddDiffs_merges_and_total(t *testing.T) {
+	t.Chdir(t.TempDir())
+	runGit(t, "init", "-q", "-b", "main")
+
+	commit := func(path, msg string, lines int) {
+		t.Helper()
+
+		if err := os.WriteFile(path, []byte(strings.Repeat("line\n", lines)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		runGit(t, "add", "-A")
+		runGit(t, "commit", "-qm", msg)
+	}
+
+	commit("a.go", "initial", 1)
+	runGit(t, "checkout", "-q", "-b", "feature")
+	commit("b.go", "feature", 1)
+	runGit(t, "checkout", "-q", "main")
+	commit("c.go", "main", 1)
+	runGit(t, "merge", "-q", "--no-edit", "feature")
+	commit("d.go", "huge", 20_000)
+
+	commits, _, err := GetCommitDetailsFromRoot(t.Context(), "HEAD", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := DiffOptions{Mode: DiffsAll, FirstParent: true}
+	if err := AddDiffs(t.Context(), commits, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Newest first: huge, the merge, main, feature, initial
+	if got := commits[0].Diff; !strings.Contains(got, "+++ b/d.go") || !strings.Contains(got, "more lines") {
+		t.Errorf("huge commit diff = %q...", got[:min(len(got), 200)])
+	}
+
+	if got := commits[1].Diff; !strings.Contains(got, "+++ b/b.go") || strings.Contains(got, "c.go") {
+		t.Errorf("merge diff against the first parent = %q", got)
+	}
+
+	for _, c := range commits[2:] {
+		if !strings.Contains(c.Diff, "+line") {
+			t.Errorf("missing patch of %q: %q", c.Subject(), c.Diff)
+		}
+	}
+
+	// Without --first-parent the merge gets no patch
+	for i := range commits {
+		commits[i].Diff = ""
+	}
+
+	if err := AddDiffs(t.Context(), commits, DiffOptions{Mode: DiffsAll}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if commits[1].Diff != "" || commits[2].Diff == "" {
+		t.Errorf("got merge diff %q and next diff %q", commits[1].Diff, commits[2].Diff)
+	}
+
+	// Once the total is reached, the other patches are not read
+	var seen int
+
+	err = commitPatches(t.Context(), commits, opts, func(*Commit, string) bool {
+		seen++
+
+		return false
+	})
+	if err != nil || seen != 1 {
+		t.Errorf("read %d patches after the first, err %v", seen-1, err)
+	}
+}
+
+func TestDiffVariant(t *testing.T) {
+	if got := DiffVariant(DiffsSmall); got != "small:2048:65536" {
+		t.Errorf("DiffVariant(small) = %q", got)
+	}
+
+	if got := DiffVariant(DiffsNone); got != "" {
+		t.Errorf("DiffVariant(none) = %q, want empty", got)
+	}
+}
+
+func TestAddDiffs_none(t *testing.T) {
+	commits := []Commit{{Hash: "abc"}}
+
+	if err := AddDiffs(t.Context(), commits, DiffOptions{Mode: DiffsNone}); err != nil || commits[0].Diff != "" {
+		t.Errorf("got %q, %v", commits[0].Diff, err)
+	}
+
+	if err := AddDiffs(t.Context(), commits, DiffOptions{Mode: "some"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
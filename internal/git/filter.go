@@ -0,0 +1,109 @@
+package git
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/glob"
+)
+
+// Filter selects the commits left out of commit details, so that noise such as
+// dependency bumps, release commits and CI-only changes does not reach the prompt.
+type Filter struct {
+	// Subjects are matched against the first line of the commit message.
+	Subjects []*regexp.Regexp
+	// Authors are matched against the author as "Name <email>".
+	Authors []*regexp.Regexp
+	// Paths are pathspec globs (e.g. ".github/**"); commits that only change matching
+	// files are left out.
+	Paths []string
+	// Merges leaves out merge commits.
+	Merges bool
+	// FirstParent follows only the first parent of merges, so a merged branch appears
+	// as its merge commit instead of the commits it brought in.
+	FirstParent bool
+}
+
+// Dropped is a commit left out by a Filter.
+type Dropped struct {
+	Hash    string
+	Subject string
+	// Reason explains which rule matched, e.g. "author matches \[bot\]".
+	Reason string
+}
+
+// apply splits commits into those kept and those dropped. A nil filter keeps every commit.
+func (f *Filter) apply(commits []Commit) ([]Commit, []Dropped) {
+	if f == nil {
+		return commits, nil
+	}
+
+	paths := make([]*regexp.Regexp, len(f.Paths))
+	for i, p := range f.Paths {
+		paths[i] = globRegexp(p)
+	}
+
+	var (
+		kept    []Commit
+		dropped []Dropped
+	)
+
+	for _, c := range commits {
+		if reason := f.reason(&c, paths); reason != "" {
+			dropped = append(dropped, Dropped{Hash: c.Hash, Subject: c.Subject(), Reason: reason})
+
+			continue
+		}
+
+		kept = append(kept, c)
+	}
+
+	return kept, dropped
+}
+
+// reason returns why c is left out, or "" if it is kept.
+func (f *Filter) reason(c *Commit, paths []*regexp.Regexp) string {
+	if f.Merges && len(c.Parents) > 1 {
+		return "merge commit"
+	}
+
+	for _, re := range f.Subjects {
+		if re.MatchString(c.Subject()) {
+			return "subject matches " + re.String()
+		}
+	}
+
+	for _, re := range f.Authors {
+		if re.MatchString(c.Author) {
+			return "author matches " + re.String()
+		}
+	}
+
+	if len(paths) > 0 && len(c.Files) > 0 && onlyMatching(c.Files, paths) {
+		return "only changes paths matching " + strings.Join(f.Paths, ", ")
+	}
+
+	return ""
+}
+
+// onlyMatching reports whether every file matches one of the paths.
+func onlyMatching(files []string, paths []*regexp.Regexp) bool {
+	for _, file := range files {
+		if !slices.ContainsFunc(paths, func(re *regexp.Regexp) bool { return re.MatchString(file) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// globRegexp converts a pathspec glob to a regular expression by the rules of glob.Regexp.
+// A pattern without wildcards also matches the files under it, as a directory.
+func globRegexp(pattern string) *regexp.Regexp {
+	if !glob.HasWildcards(pattern) {
+		return regexp.MustCompile("^" + regexp.QuoteMeta(strings.TrimSuffix(pattern, "/")) + "(/.*)?$")
+	}
+
+	return glob.Regexp(pattern)
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{".github/**", ".github/workflows/ci.yml", true},
+		{".github/**", "src/.github", false},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/guide.md", false},
+		{"**/*.md", "docs/guide.md", true},
+		{"**/*.md", "README.md", true},
+		{"docs", "docs/guide.md", true},
+		{"docs", "docsite/index.html", false},
+		{"go.?um", "go.sum", true},
+	}
+
+	for _, tt := range tests {
+		if got := globRegexp(tt.pattern).MatchString(tt.path); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+// newFilterRepo creates a repository with a feature branch merged into main after v1.0.0,
+// a dependency bump by a bot and a CI-only change.
+func newFilterRepo(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	commit := func(path, msg string, args ...string) {
+		t.Helper()
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(msg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		runGit(t, "add", "-A")
+		runGit(t, append([]string{"commit", "-qm", msg}, args...)...)
+	}
+
+	runGit(t, "init", "-q", "-b", "main")
+	commit("main.go", "initial")
+	runGit(t, "tag", "v1.0.0")
+	runGit(t, "checkout", "-q", "-b", "feature")
+	commit("feature.go", "feat: add feature")
+	commit("feature_test.go", "test: cover feature")
+	runGit(t, "checkout", "-q", "main")
+	commit("go.sum", "Bump x from 1.0 to 1.1", "--author", "dependabot[bot] <bot@example.com>")
+	commit(".github/workflows/ci.yml", "Tweak CI")
+	runGit(t, "merge", "-q", "--no-ff", "-m", "Merge branch 'feature'", "feature")
+}
+
+func TestGetCommitDetails_filter(t *testing.T) {
+	newFilterRepo(t)
+
+	filter := &Filter{
+		Authors: []*regexp.Regexp{regexp.MustCompile(`\[bot\]`)},
+		Paths:   []string{".github/**"},
+		Merges:  true,
+	}
+
+	commits, dropped, err := GetCommitDetails(t.Context(), "v1.0.0", "HEAD", nil, filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := FormatCommits(commits)
+
+	if !strings.Contains(got, "feat: add feature") || !strings.Contains(got, "test: cover feature") {
+		t.Errorf("missing branch commits:\n%s", got)
+	}
+
+	reasons := map[string]string{}
+	for _, d := range dropped {
+		reasons[d.Subject] = d.Reason
+	}
+
+	want := map[string]string{
+		"Merge branch 'feature'": "merge commit",
+		"Bump x from 1.0 to 1.1": `author matches \[bot\]`,
+		"Tweak CI":               "only changes paths matching .github/**",
+	}
+
+	if len(reasons) != len(want) {
+		t.Fatalf("dropped = %+v, want %v", dropped, want)
+	}
+
+	for subject, reason := range want {
+		if reasons[subject] != reason {
+			t.Errorf("%q: reason = %q, want %q", subject, reasons[subject], reason)
+		}
+	}
+}
+
+func TestGetCommitDetails_first_parent(t *testing.T) {
+	newFilterRepo(t)
+
+	commits, dropped, err := GetCommitDetails(t.Context(), "v1.0.0", "HEAD", nil, &Filter{FirstParent: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := FormatCommits(commits)
+
+	if len(dropped) != 0 {
+		t.Errorf("dropped = %+v, want none", dropped)
+	}
+
+	if strings.Contains(got, "feat: add feature") {
+		t.Errorf("branch commits included with --first-parent:\n%s", got)
+	}
+
+	// The merge commit carries the changes of the branch
+	if !strings.Contains(got, "Merge branch 'feature'") || !strings.Contains(got, "feature_test.go") {
+		t.Errorf("missing merge commit with its changes:\n%s", got)
+	}
+}
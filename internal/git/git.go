@@ -2,18 +2,32 @@
 package git
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/proc"
 )
 
 const commitDelimiter = "---HERALD-COMMIT---"
 
+// command returns a git command. Git runs in a process group of its own (see proc.Command),
+// in the background, where reading from the terminal would stop it until a timeout; so it
+// does not prompt for credentials, and fails instead.
+func command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := proc.Command(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	return cmd
+}
+
 // FindRepoRoot walks up from the current directory to find the git repository root.
 func FindRepoRoot() (string, error) {
 	dir, err := os.Getwd()
@@ -38,73 +52,379 @@ func FindRepoRoot() (string, error) {
 
 // FetchTags fetches tags from the remote so locally-missing tags
 // (e.g. tags created by CI) are available for git log.
-func FetchTags() error {
-	cmd := exec.Command("git", "fetch", "--tags", "--quiet")
+func FetchTags(ctx context.Context) error {
+	cmd := command(ctx, "fetch", "--tags", "--quiet")
+
+	// Nor may ssh ask for a passphrase or a host key confirmation; an ssh command of the
+	// user's own is left alone
+	if !hasSSHCommand(ctx) {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return errors.Runtime("failed to fetch tags", err)
+		return errors.Runtime("failed to fetch tags", fmt.Errorf("%s", strings.TrimSpace(stderr.String())))
 	}
 
 	return nil
 }
 
+// hasSSHCommand reports whether the user chose the ssh command git runs, in the environment
+// or with core.sshCommand.
+func hasSSHCommand(ctx context.Context) bool {
+	if os.Getenv("GIT_SSH_COMMAND") != "" || os.Getenv("GIT_SSH") != "" {
+		return true
+	}
+
+	out, err := command(ctx, "config", "core.sshCommand").Output()
+
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
+
 // TagExists checks if a tag exists in the repository.
-func TagExists(tag string) bool {
-	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", tag)
+func TagExists(ctx context.Context, tag string) bool {
+	cmd := command(ctx, "rev-parse", "--verify", "--quiet", tag)
+
+	return cmd.Run() == nil
+}
+
+// RefExists checks if a ref (tag, branch or commit) resolves to a commit.
+func RefExists(ctx context.Context, ref string) bool {
+	cmd := command(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
 
 	return cmd.Run() == nil
 }
 
-// GetCommitDetails returns detailed commit information between two refs.
+// LatestTag returns the most recent tag reachable from ref, or "" if there is none.
+// A non-empty match limits tags to a glob pattern such as "api/*".
+func LatestTag(ctx context.Context, ref, match string) (string, error) {
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	if match != "" {
+		args = append(args, "--match", match)
+	}
+
+	cmd := command(ctx, append(args, ref)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "No names found") || strings.Contains(msg, "No tags can describe") {
+			return "", nil
+		}
+
+		return "", errors.Runtime("failed to find the latest tag of "+ref, fmt.Errorf("%s", msg))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Tag is a git tag with the date it was created (the tagger date of annotated tags,
+// the commit date of lightweight ones).
+type Tag struct {
+	Name string
+	Date time.Time
+}
+
+// ListTags returns all tags in the repository.
+func ListTags(ctx context.Context) ([]Tag, error) {
+	cmd := command(ctx, "tag", "--list", "--format=%(refname:strip=2)%09%(creatordate:iso-strict)")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Runtime("failed to list tags", fmt.Errorf("%s", strings.TrimSpace(stderr.String())))
+	}
+
+	return parseTags(stdout.String())
+}
+
+func parseTags(output string) ([]Tag, error) {
+	var tags []Tag
+
+	for line := range strings.SplitSeq(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+
+		name, date, _ := strings.Cut(line, "\t")
+
+		t, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			return nil, errors.Runtime("failed to parse date of tag "+name, err)
+		}
+
+		tags = append(tags, Tag{Name: name, Date: t})
+	}
+
+	return tags, nil
+}
+
+// NearestTag returns the closest of the candidate tags reachable from tag, other than
+// tag itself, or "" if none of them is an ancestor. The closest is the first one met
+// walking the history of tag from its tip, parents never before their children.
+func NearestTag(ctx context.Context, tag string, candidates []string) (string, error) {
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	merged, err := mergedTags(ctx, tag)
+	if err != nil {
+		return "", err
+	}
+
+	// Tagged commits of the reachable candidates, each with its tags in the order given
+	byCommit := map[string][]string{}
+
+	for _, c := range candidates {
+		if commit, ok := merged[c]; ok && c != tag {
+			byCommit[commit] = append(byCommit[commit], c)
+		}
+	}
+
+	if len(byCommit) == 0 {
+		return "", nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := command(ctx, "rev-list", "--topo-order", tag)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", errors.Runtime("failed to find the tag nearest to "+tag, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return "", errors.Runtime("failed to find the tag nearest to "+tag, err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if tags, ok := byCommit[scanner.Text()]; ok {
+			// The rest of the history is not needed
+			cancel()
+			_ = cmd.Wait()
+
+			return tags[0], nil
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return "", errors.Runtime("failed to find the tag nearest to "+tag,
+			fmt.Errorf("%s", strings.TrimSpace(stderr.String())))
+	}
+
+	return "", nil
+}
+
+// mergedTags returns the tags reachable from ref, by name, with the commits they point to.
+func mergedTags(ctx context.Context, ref string) (map[string]string, error) {
+	cmd := command(ctx, "for-each-ref", "--merged", ref,
+		"--format=%(refname:strip=2)%09%(objectname)%09%(*objectname)", "refs/tags")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Runtime("failed to list the tags reachable from "+ref,
+			fmt.Errorf("%s", strings.TrimSpace(stderr.String())))
+	}
+
+	tags := map[string]string{}
+
+	for line := range strings.SplitSeq(strings.TrimSpace(stdout.String()), "\n") {
+		name, object, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+
+		// Annotated tags point to a tag object; the commit is the object it peels to
+		object, commit, _ := strings.Cut(object, "\t")
+		if commit == "" {
+			commit = object
+		}
+
+		tags[name] = commit
+	}
+
+	return tags, nil
+}
+
+// IsAncestor reports whether ancestor is reachable from ref.
+func IsAncestor(ctx context.Context, ancestor, ref string) (bool, error) {
+	cmd := command(ctx, "merge-base", "--is-ancestor", ancestor, ref)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	// Exit code 1 means "not an ancestor"; anything else is a failure such as an unknown ref
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, errors.Runtime("failed to check whether "+ancestor+" is an ancestor of "+ref,
+			fmt.Errorf("%s", strings.TrimSpace(stderr.String())))
+	}
+
+	return true, nil
+}
+
+// GetCommitDetails returns detailed commit information between two refs, newest first.
 // Each commit includes: full hash, full message (header + body), and list of changed files.
-func GetCommitDetails(from, to string) (string, error) {
-	return getCommitDetails(from + ".." + to)
+// Non-empty paths (pathspec globs such as "services/api/**") limit commits and file lists
+// to those paths. Commits matched by the filter are left out and returned as dropped.
+func GetCommitDetails(
+	ctx context.Context, from, to string, paths []string, filter *Filter,
+) ([]Commit, []Dropped, error) {
+	return getCommitDetails(ctx, from+".."+to, paths, filter)
 }
 
 // GetCommitDetailsFromRoot returns detailed commit information from root to the given ref.
-func GetCommitDetailsFromRoot(to string) (string, error) {
-	return getCommitDetails(to)
+func GetCommitDetailsFromRoot(
+	ctx context.Context, to string, paths []string, filter *Filter,
+) ([]Commit, []Dropped, error) {
+	return getCommitDetails(ctx, to, paths, filter)
 }
 
-func getCommitDetails(revRange string) (string, error) {
-	format := fmt.Sprintf("%s%%n%%H%%n%%B%%n%s-STAT", commitDelimiter, commitDelimiter)
-	cmd := exec.Command("git", "log", "--stat", "--format="+format, revRange)
+func getCommitDetails(
+	ctx context.Context, revRange string, paths []string, filter *Filter,
+) ([]Commit, []Dropped, error) {
+	format := fmt.Sprintf("%s%%n%%H%%x09%%P%%x09%%an <%%ae>%%n%%B%%n%s-STAT", commitDelimiter, commitDelimiter)
+	args := []string{"log", "--stat", "--format=" + format}
+
+	if filter != nil && filter.FirstParent {
+		args = append(args, "--first-parent")
+	}
+
+	args = append(args, revRange)
+
+	if len(paths) > 0 {
+		args = append(args, "--")
+		for _, p := range paths {
+			args = append(args, ":(glob)"+p)
+		}
+	}
+
+	cmd := command(ctx, args...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", errors.Runtime("failed to get commit details", err)
+		return nil, nil, errors.Runtime("failed to get commit details", err)
+	}
+
+	commits := parseCommits(stdout.String(), commitDelimiter)
+
+	// Path rules look at every file of a commit, not only those within paths
+	if filter != nil && len(filter.Paths) > 0 {
+		files, err := changedFiles(ctx, revRange, filter.FirstParent)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i := range commits {
+			commits[i].Files = files[commits[i].Hash]
+		}
 	}
 
-	return parseCommitDetails(stdout.String(), commitDelimiter)
+	kept, dropped := filter.apply(commits)
+
+	return kept, dropped, nil
 }
 
-func parseCommitDetails(output, delim string) (string, error) {
-	output = strings.TrimSpace(output)
-	if output == "" {
-		return "(no commits)", nil
+// changedFiles returns the files changed by each commit in revRange, keyed by hash.
+func changedFiles(ctx context.Context, revRange string, firstParent bool) (map[string][]string, error) {
+	args := []string{"log", "--name-only", "--format=" + commitDelimiter + "%n%H"}
+	if firstParent {
+		args = append(args, "--first-parent")
+	}
+
+	cmd := command(ctx, append(args, revRange)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Runtime("failed to list changed files", fmt.Errorf("%s", strings.TrimSpace(stderr.String())))
 	}
 
-	// Split on the commit delimiter to get individual commit blocks
-	startMarker := delim
+	files := map[string][]string{}
+
+	for block := range strings.SplitSeq(stdout.String(), commitDelimiter+"\n") {
+		hash, names, _ := strings.Cut(strings.TrimSpace(block), "\n")
+		if hash == "" {
+			continue
+		}
+
+		for name := range strings.SplitSeq(names, "\n") {
+			if name != "" {
+				files[hash] = append(files[hash], name)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// Commit is a commit parsed from git log output.
+type Commit struct {
+	Hash    string
+	Parents []string
+	Author  string
+	Message string
+	Stat    string
+	// Files are all files changed by the commit; only collected for path rules.
+	Files []string
+	// Diff is the patch embedded in the prompt; only collected by AddDiffs.
+	Diff string
+	// Conventional is the parsed Conventional Commits header, or nil if the message
+	// neither follows the convention nor has a BREAKING CHANGE footer.
+	Conventional *Conventional
+}
+
+// Subject returns the first line of the commit message.
+func (c *Commit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+
+	return subject
+}
+
+func parseCommits(output, delim string) []Commit {
+	output = strings.TrimSpace(output)
+
+	// Split on the commit delimiter to get individual commit blocks. The delimiter is
+	// also a prefix of the stat marker, so only match it at the end of a line.
+	startMarker := delim + "\n"
 	blocks := strings.Split(output, startMarker)
 
-	var result strings.Builder
-	first := true
+	var commits []Commit
 
 	for _, block := range blocks {
 		block = strings.TrimSpace(block)
-		if block == "" || block == "-STAT" {
+		if block == "" {
 			continue
 		}
 
 		// Each block has format:
-		// <hash>
+		// <hash>\t<parent hashes>\t<author name> <<author email>>
 		// <message body>
 		//
 		// <delim>-STAT
@@ -119,43 +439,81 @@ func parseCommitDetails(output, delim string) (string, error) {
 			continue
 		}
 
-		// First line is the hash, rest is the message
+		// First line is the hash with parents and author, rest is the message
 		lines := strings.SplitN(headerPart, "\n", 2)
-		hash := strings.TrimSpace(lines[0])
+		fields := strings.Split(lines[0], "\t")
 
-		if hash == "" {
+		c := Commit{Hash: strings.TrimSpace(fields[0])}
+		if c.Hash == "" {
 			continue
 		}
 
-		var message string
+		if len(fields) > 1 {
+			c.Parents = strings.Fields(fields[1])
+		}
+
+		if len(fields) > 2 {
+			c.Author = fields[2]
+		}
+
 		if len(lines) > 1 {
-			message = strings.TrimSpace(lines[1])
+			c.Message = strings.TrimSpace(lines[1])
+			c.Conventional = ParseConventional(c.Message)
 		}
 
-		var stat string
 		if len(parts) > 1 {
-			stat = strings.TrimSpace(parts[1])
+			c.Stat = strings.TrimSpace(parts[1])
 		}
 
-		if !first {
+		commits = append(commits, c)
+	}
+
+	return commits
+}
+
+// FormatCommits renders commits for the prompt; "(no commits)" if there are none.
+func FormatCommits(commits []Commit) string {
+	if len(commits) == 0 {
+		return "(no commits)"
+	}
+
+	var result strings.Builder
+
+	for i, c := range commits {
+		if i > 0 {
 			result.WriteString("\n---\n\n")
 		}
 
-		first = false
-
-		fmt.Fprintf(&result, "Commit: %s\n\n", hash)
-		result.WriteString(message)
+		fmt.Fprintf(&result, "Commit: %s\n\n", c.Hash)
+		result.WriteString(c.Message)
 		result.WriteString("\n\nChanged files:\n")
 
-		if stat != "" {
-			result.WriteString(stat)
+		if c.Stat != "" {
+			result.WriteString(c.Stat)
+			result.WriteString("\n")
+		}
+
+		if c.Diff != "" {
+			result.WriteString("\nDiff:\n")
+			result.WriteString(c.Diff)
 			result.WriteString("\n")
 		}
 	}
 
-	if first {
-		return "(no commits)", nil
+	return result.String()
+}
+
+// RemoteURL returns the URL of the given remote (e.g. "origin").
+func RemoteURL(ctx context.Context, name string) (string, error) {
+	cmd := command(ctx, "remote", "get-url", name)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.Runtime("failed to get URL of remote "+name, err)
 	}
 
-	return result.String(), nil
+	return strings.TrimSpace(stdout.String()), nil
 }
@@ -1,6 +1,13 @@
 package git
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -9,10 +16,7 @@ func TestParseCommitDetails_single(t *testing.T) {
 	delim := "---DELIM---"
 	input := delim + "\nabc123\nfeat: add feature\n\n" + delim + "-STAT\n file.go | 10 ++++\n 1 file changed"
 
-	got, err := parseCommitDetails(input, delim)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	got := FormatCommits(parseCommits(input, delim))
 
 	if !strings.Contains(got, "Commit: abc123") {
 		t.Error("missing commit hash")
@@ -36,10 +40,7 @@ func TestParseCommitDetails_multiple(t *testing.T) {
 	input := delim + "\naaa111\nfirst commit\n\n" + delim + "-STAT\n a.go | 1 +\n" +
 		delim + "\nbbb222\nsecond commit\n\n" + delim + "-STAT\n b.go | 2 ++\n"
 
-	got, err := parseCommitDetails(input, delim)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	got := FormatCommits(parseCommits(input, delim))
 
 	if !strings.Contains(got, "Commit: aaa111") {
 		t.Error("missing first commit hash")
@@ -54,13 +55,282 @@ func TestParseCommitDetails_multiple(t *testing.T) {
 	}
 }
 
+func TestParseCommitDetails_stat_belongs_to_commit(t *testing.T) {
+	delim := "---DELIM---"
+	input := delim + "\naaa111\nfirst commit\n\n" + delim + "-STAT\n\n a.go | 1 +\n" +
+		delim + "\nbbb222\nsecond commit\n\n" + delim + "-STAT\n\n b.go | 2 ++\n"
+
+	got := FormatCommits(parseCommits(input, delim))
+
+	if strings.Count(got, "Commit: ") != 2 {
+		t.Errorf("got %d commits, want 2:\n%s", strings.Count(got, "Commit: "), got)
+	}
+
+	if !strings.Contains(got, "Changed files:\na.go | 1 +") || !strings.Contains(got, "Changed files:\nb.go | 2 ++") {
+		t.Errorf("changed files not attached to their commits:\n%s", got)
+	}
+}
+
 func TestParseCommitDetails_empty(t *testing.T) {
-	got, err := parseCommitDetails("", "---DELIM---")
+	got := FormatCommits(parseCommits("", "---DELIM---"))
+
+	if got != "(no commits)" {
+		t.Errorf("got %q, want %q", got, "(no commits)")
+	}
+}
+
+func TestParseCommitDetails_exclude(t *testing.T) {
+	delim := "---DELIM---"
+	input := delim + "\naaa111\nchore(deps): bump x\n\n" + delim + "-STAT\n go.mod | 1 +\n" +
+		delim + "\nbbb222\nfeat: add y\n\n" + delim + "-STAT\n b.go | 2 ++\n"
+
+	filter := &Filter{Subjects: []*regexp.Regexp{regexp.MustCompile(`^chore\(deps\)`)}}
+
+	kept, dropped := filter.apply(parseCommits(input, delim))
+
+	got := FormatCommits(kept)
+	if strings.Contains(got, "aaa111") || !strings.Contains(got, "Commit: bbb222") {
+		t.Errorf("got %q", got)
+	}
+
+	want := []Dropped{{Hash: "aaa111", Subject: "chore(deps): bump x", Reason: `subject matches ^chore\(deps\)`}}
+	if !reflect.DeepEqual(dropped, want) {
+		t.Errorf("dropped = %+v, want %+v", dropped, want)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	input := "v1.0.0\t2024-01-02T10:00:00+01:00\nv1.1.0\t2024-03-04T12:30:00Z\n"
+
+	got, err := parseTags(input)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if got != "(no commits)" {
-		t.Errorf("got %q, want %q", got, "(no commits)")
+	if len(got) != 2 {
+		t.Fatalf("got %d tags, want 2", len(got))
+	}
+
+	if got[0].Name != "v1.0.0" || got[0].Date.UTC().Hour() != 9 {
+		t.Errorf("got %+v", got[0])
+	}
+
+	if got[1].Name != "v1.1.0" || got[1].Date.Month() != 3 {
+		t.Errorf("got %+v", got[1])
+	}
+}
+
+func TestParseTags_empty(t *testing.T) {
+	got, err := parseTags("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("got %v, want no tags", got)
+	}
+}
+
+func TestParseTags_invalid_date(t *testing.T) {
+	if _, err := parseTags("v1.0.0\tyesterday"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// runGit runs a git command in the working directory with an isolated configuration.
+func runGit(t *testing.T, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestFetchTags_does_not_prompt(t *testing.T) {
+	newTestRepo(t)
+	runGit(t, "remote", "add", "origin", "ssh://git.example.com/repo.git")
+
+	// A fake ssh records its arguments and fails, as for a key that needs a passphrase
+	bin := t.TempDir()
+	args := filepath.Join(bin, "args")
+	script := "#!/bin/sh\necho \"$@\" >> " + args + "\nexit 255\n"
+
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	// Restored after the test, unset during it
+	t.Setenv("GIT_SSH_COMMAND", "")
+	os.Unsetenv("GIT_SSH_COMMAND")
+
+	if err := FetchTags(t.Context()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	runGit(t, "config", "core.sshCommand", "ssh -i work-key")
+
+	if err := FetchTags(t.Context()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	data, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 ||
+		!strings.HasPrefix(lines[0], "-o BatchMode=yes ") || !strings.HasPrefix(lines[1], "-i work-key ") {
+		t.Errorf("ssh ran with:\n%s", data)
+	}
+
+	if env := command(t.Context(), "version").Env; !slices.Contains(env, "GIT_TERMINAL_PROMPT=0") {
+		t.Error("git may prompt for credentials")
+	}
+}
+
+// newTestRepo creates a repository in a temporary directory and makes it the working
+// directory: v1.0.0 and v1.1.0 on the main line, and v1.0.1 on a branch from v1.0.0.
+func newTestRepo(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	commit := func(msg string) {
+		runGit(t, "commit", "--allow-empty", "-qm", msg)
+	}
+
+	runGit(t, "init", "-q", "-b", "main")
+	commit("initial")
+	runGit(t, "tag", "v1.0.0")
+	commit("feature")
+	runGit(t, "tag", "v1.1.0")
+	runGit(t, "checkout", "-q", "-b", "maint", "v1.0.0")
+	commit("fix")
+	runGit(t, "tag", "v1.0.1")
+	runGit(t, "checkout", "-q", "main")
+}
+
+func TestIsAncestor(t *testing.T) {
+	newTestRepo(t)
+
+	tests := []struct {
+		ancestor, ref string
+		want          bool
+	}{
+		{"v1.0.0", "v1.1.0", true},
+		{"v1.0.0", "v1.0.1", true},
+		{"v1.0.1", "v1.1.0", false},
+		{"v1.1.0", "v1.0.0", false},
+	}
+
+	for _, tt := range tests {
+		got, err := IsAncestor(t.Context(), tt.ancestor, tt.ref)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got != tt.want {
+			t.Errorf("IsAncestor(%s, %s) = %v, want %v", tt.ancestor, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestIsAncestor_unknown_ref(t *testing.T) {
+	newTestRepo(t)
+
+	if _, err := IsAncestor(t.Context(), "v9.9.9", "v1.0.0"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestNearestTag(t *testing.T) {
+	newTestRepo(t)
+
+	tests := []struct {
+		tag        string
+		candidates []string
+		want       string
+	}{
+		{"v1.1.0", []string{"v1.0.0", "v1.0.1"}, "v1.0.0"},
+		{"v1.0.1", []string{"v1.0.0", "v1.1.0"}, "v1.0.0"},
+		{"v1.0.0", []string{"v1.0.1", "v1.1.0"}, ""},
+		{"v1.1.0", nil, ""},
+	}
+
+	for _, tt := range tests {
+		got, err := NearestTag(t.Context(), tt.tag, tt.candidates)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got != tt.want {
+			t.Errorf("NearestTag(%s, %v) = %q, want %q", tt.tag, tt.candidates, got, tt.want)
+		}
+	}
+}
+
+func TestNearestTag_annotated_tags_among_many_candidates(t *testing.T) {
+	newTestRepo(t)
+	runGit(t, "tag", "-a", "-m", "release", "v1.1.1", "v1.1.0")
+	runGit(t, "commit", "--allow-empty", "-qm", "next")
+	runGit(t, "tag", "-a", "-m", "release", "v1.2.0")
+
+	// Far more candidates than fit in a command line, most of them not tags at all
+	candidates := []string{"v1.0.0", "v1.1.1"}
+	for i := range 100000 {
+		candidates = append(candidates, fmt.Sprintf("v0.0.%d-not-a-tag", i))
+	}
+
+	got, err := NearestTag(t.Context(), "v1.2.0", candidates)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "v1.1.1" {
+		t.Errorf("got %q, want v1.1.1", got)
+	}
+}
+
+func TestGetCommitDetails_paths(t *testing.T) {
+	t.Chdir(t.TempDir())
+	runGit(t, "init", "-q", "-b", "main")
+
+	commit := func(path, msg string) {
+		t.Helper()
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(msg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		runGit(t, "add", "-A")
+		runGit(t, "commit", "-qm", msg)
+	}
+
+	commit("services/api/main.go", "api: initial")
+	runGit(t, "tag", "api/v1.0.0")
+	commit("services/api/handler.go", "api: add handler")
+	commit("web/index.html", "web: restyle")
+
+	commits, _, err := GetCommitDetails(t.Context(), "api/v1.0.0", "HEAD", []string{"services/api/**"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := FormatCommits(commits)
+
+	if !strings.Contains(got, "api: add handler") || !strings.Contains(got, "services/api/handler.go") {
+		t.Errorf("missing api commit:\n%s", got)
+	}
+
+	if strings.Contains(got, "web: restyle") {
+		t.Errorf("commit outside the paths included:\n%s", got)
 	}
 }
@@ -0,0 +1,46 @@
+// Package glob matches slash-separated names, such as file paths and release tags, against
+// glob patterns.
+package glob
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Regexp converts a glob pattern to a regular expression matching whole names. The rules
+// are those of git pathspec globs:
+//   - "*" matches any sequence of characters except "/", and "?" any one character but "/"
+//   - "**/" matches any number of leading directories, including none
+//   - any other "**", such as a trailing "/**", matches any sequence of characters
+//   - all other characters match themselves
+func Regexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	b.WriteString("$")
+
+	return regexp.MustCompile(b.String())
+}
+
+// HasWildcards reports whether a pattern contains "*" or "?", rather than naming one name.
+func HasWildcards(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?")
+}
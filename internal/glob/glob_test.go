@@ -0,0 +1,33 @@
+package glob
+
+import "testing"
+
+func TestRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.md", "README.md", true},
+		{"*.md", "docs/guide.md", false},
+		{"**/*.md", "docs/guide.md", true},
+		{"**/*.md", "README.md", true},
+		{".github/**", ".github/workflows/ci.yml", true},
+		{".github/**", "src/.github", false},
+		{"go.?um", "go.sum", true},
+		{"v?", "v/", false},
+		{"v*", "v1.2.0", true},
+		{"v*", "api/v1.2.0", false},
+		{"api/v*", "api/v1.2.0", true},
+		{"**v1.0.?", "api/v1.0.0", true},
+		{"v1.0.0+x", "v1.0.0+x", true},
+		{"v1.0.0+x", "v1.0.0x", false},
+		{"v[1]", "v1", false},
+	}
+
+	for _, tt := range tests {
+		if got := Regexp(tt.pattern).MatchString(tt.name); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
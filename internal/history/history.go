@@ -0,0 +1,181 @@
+// Package history archives release bodies before herald replaces them,
+// so that earlier versions can be listed and restored.
+package history
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// Actions that replaced an archived body.
+const (
+	ActionUpdate   = "update"
+	ActionRollback = "rollback"
+)
+
+// Entry is a release body as it was before herald replaced it.
+type Entry struct {
+	// Time is when the body was replaced.
+	Time time.Time `json:"time"`
+	Tag  string    `json:"tag"`
+	// HeraldVersion is the version of the run that replaced the body.
+	HeraldVersion string `json:"heraldVersion"`
+	// Model is the model that wrote Body, if herald did and the body was archived since.
+	Model string `json:"bodyModel,omitempty"`
+	// NextModel is the model that wrote the body that replaced Body; it becomes the Model
+	// of that body once it is replaced in turn. Older versions stored it as "model" too.
+	NextModel string `json:"model,omitempty"`
+	Action    string `json:"action"`
+	Body      string `json:"body"`
+}
+
+// Store keeps the history of each release of one repository in a JSON file per tag.
+type Store struct {
+	dir string
+}
+
+// Open returns the store for a repository ("owner/name") under DefaultDir.
+func Open(repo string) (*Store, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(filepath.Join(dir, filepath.FromSlash(repo))), nil
+}
+
+// New returns a store that keeps its files in dir.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns $XDG_DATA_HOME/herald/history, or ~/.local/share/herald/history if unset.
+func DefaultDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Environment("cannot determine the history directory", err)
+		}
+
+		dir = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dir, "herald", "history"), nil
+}
+
+// Add appends an entry to the history of its tag.
+func (s *Store) Add(e Entry) error {
+	entries, err := s.load(e.Tag)
+	if err != nil {
+		return err
+	}
+
+	return s.save(e.Tag, append(entries, e))
+}
+
+// Remove takes back the most recent entry equal to e, such as one archived for an update
+// that then failed. The history of the tag is removed with its last entry.
+func (s *Store) Remove(e Entry) error {
+	entries, err := s.load(e.Tag)
+	if err != nil {
+		return err
+	}
+
+	i := len(entries) - 1
+	for i >= 0 && !(entries[i].Time.Equal(e.Time) && entries[i].Action == e.Action && entries[i].Body == e.Body) {
+		i--
+	}
+
+	if i < 0 {
+		return nil
+	}
+
+	entries = slices.Delete(entries, i, i+1)
+	if len(entries) == 0 {
+		if err := os.Remove(s.path(e.Tag)); err != nil {
+			return errors.Runtime("failed to remove release history", err)
+		}
+
+		return nil
+	}
+
+	return s.save(e.Tag, entries)
+}
+
+// save replaces the history of a tag. The file is replaced atomically, so an interrupted
+// run never leaves a truncated history.
+func (s *Store) save(tag string, entries []Entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return errors.Runtime("failed to encode release history", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return errors.Runtime("failed to create history directory", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return errors.Runtime("failed to write release history", err)
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(tag))
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return errors.Runtime("failed to write release history", err)
+	}
+
+	return nil
+}
+
+// List returns the archived bodies of a release, most recent first.
+func (s *Store) List(tag string) ([]Entry, error) {
+	entries, err := s.load(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(entries)
+
+	return entries, nil
+}
+
+func (s *Store) load(tag string) ([]Entry, error) {
+	data, err := os.ReadFile(s.path(tag))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Runtime("failed to read release history", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.Runtime("failed to parse release history "+s.path(tag), err)
+	}
+
+	return entries, nil
+}
+
+// path returns the history file of a tag; tags may contain slashes, so they are escaped.
+func (s *Store) path(tag string) string {
+	return filepath.Join(s.dir, strings.ReplaceAll(url.PathEscape(tag), "%", "_")+".json")
+}
@@ -0,0 +1,79 @@
+package history
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStore_add_and_list(t *testing.T) {
+	s := New(t.TempDir())
+
+	for i, body := range []string{"first", "second"} {
+		e := Entry{Time: time.Unix(int64(i), 0), Tag: "v1.0", HeraldVersion: "1.0.0", Action: ActionUpdate, Body: body}
+		if err := s.Add(e); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	if err := s.Add(Entry{Tag: "v2.0", Body: "other"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	got, err := s.List("v1.0")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+
+	if len(got) != 2 || got[0].Body != "second" || got[1].Body != "first" {
+		t.Errorf("got %+v, want most recent first", got)
+	}
+}
+
+func TestStore_remove(t *testing.T) {
+	s := New(t.TempDir())
+	first := Entry{Time: time.Unix(1, 0), Tag: "v1.0", Action: ActionUpdate, Body: "first"}
+	second := Entry{Time: time.Unix(2, 0), Tag: "v1.0", Action: ActionUpdate, Body: "second"}
+
+	for _, e := range []Entry{first, second} {
+		if err := s.Add(e); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	if err := s.Remove(second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := s.List("v1.0"); len(got) != 1 || got[0].Body != "first" {
+		t.Errorf("got %+v, want the first entry only", got)
+	}
+
+	if err := s.Remove(first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if entries, err := os.ReadDir(s.dir); err != nil || len(entries) != 0 {
+		t.Errorf("got %v, %v, want an empty directory", entries, err)
+	}
+}
+
+func TestStore_list_unknown_tag(t *testing.T) {
+	got, err := New(t.TempDir()).List("v1.0")
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
+
+func TestStore_tag_with_slash(t *testing.T) {
+	s := New(t.TempDir())
+
+	if err := s.Add(Entry{Tag: "api/v1.0", Body: "notes"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	got, err := s.List("api/v1.0")
+	if err != nil || len(got) != 1 {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/retry"
+)
+
+const (
+	anthropicDefaultURL   = "https://api.anthropic.com"
+	anthropicVersion      = "2023-06-01"
+	anthropicDefaultModel = "claude-sonnet-4-5"
+	anthropicMaxTokens    = 8192
+)
+
+// anthropicTransientErrors are the error types of a streamed response that may pass
+// when the request is repeated.
+//
+//nolint:gochecknoglobals // static lookup table
+var anthropicTransientErrors = map[string]bool{
+	"overloaded_error": true,
+	"rate_limit_error": true,
+	"api_error":        true,
+}
+
+// anthropicAliases maps the short model names accepted by the claude CLI
+// to Messages API model identifiers, so --model works the same for both backends.
+//
+//nolint:gochecknoglobals // static lookup table
+var anthropicAliases = map[string]string{
+	"haiku":  "claude-haiku-4-5",
+	"sonnet": "claude-sonnet-4-5",
+	"opus":   "claude-opus-4-1",
+}
+
+// anthropicAPI generates notes via the Anthropic Messages API.
+type anthropicAPI struct {
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+func newAnthropic(baseURL, apiKey, model string) *anthropicAPI {
+	if model == "" {
+		model = anthropicDefaultModel
+	}
+
+	if full, ok := anthropicAliases[model]; ok {
+		model = full
+	}
+
+	return &anthropicAPI{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, model: model}
+}
+
+func (a *anthropicAPI) Name() string { return "Anthropic API (" + a.model + ")" }
+
+// Check verifies an API key is configured.
+func (a *anthropicAPI) Check(context.Context) error {
+	if a.apiKey == "" {
+		return errors.Environment("ANTHROPIC_API_KEY is not set", nil)
+	}
+
+	return nil
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	Messages  []anthropicMessage `json:"messages"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// anthropicEvent is a server-sent event of a streamed response; only text deltas and
+// errors are used.
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (a *anthropicAPI) request(prompt string) anthropicRequest {
+	return anthropicRequest{
+		Model:     a.model,
+		MaxTokens: anthropicMaxTokens,
+		Messages:  []anthropicMessage{{Role: "user", Content: prompt}},
+	}
+}
+
+func (a *anthropicAPI) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         a.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+// Generate sends the prompt as a single user message and returns the concatenated text blocks.
+func (a *anthropicAPI) Generate(ctx context.Context, prompt string) (string, error) {
+	var resp anthropicResponse
+	if err := doJSON(ctx, http.MethodPost, a.baseURL+"/v1/messages", a.headers(), a.request(prompt), &resp); err != nil {
+		return "", errors.Runtime("failed to generate notes with Anthropic API", err)
+	}
+
+	var b strings.Builder
+
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			b.WriteString(block.Text)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// Stream requests server-sent events and writes the text deltas to w as they arrive.
+func (a *anthropicAPI) Stream(ctx context.Context, prompt string, w io.Writer) (string, error) {
+	req := a.request(prompt)
+	req.Stream = true
+
+	var b strings.Builder
+
+	err := doStream(ctx, a.baseURL+"/v1/messages", a.headers(), req, func(line string) error {
+		data, ok := sseData(line)
+		if !ok {
+			return nil
+		}
+
+		var ev anthropicEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+
+		switch {
+		case ev.Type == "content_block_delta" && ev.Delta.Type == "text_delta":
+			b.WriteString(ev.Delta.Text)
+			_, _ = io.WriteString(w, ev.Delta.Text)
+		case ev.Type == "error":
+			err := fmt.Errorf("%s: %s", ev.Error.Type, ev.Error.Message)
+			if anthropicTransientErrors[ev.Error.Type] {
+				return retry.Retryable(err, 0)
+			}
+
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", errors.Runtime("failed to generate notes with Anthropic API", err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,229 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+	"github.com/AndreyAkinshin/herald/internal/proc"
+	"github.com/AndreyAkinshin/herald/internal/retry"
+)
+
+// claudeCLI generates notes by piping the prompt into `claude -p`.
+type claudeCLI struct {
+	model string
+}
+
+// Name returns "Claude", with the model if one is set, to tell the models of a fallback chain apart.
+func (c *claudeCLI) Name() string {
+	if c.model == "" {
+		return "Claude"
+	}
+
+	return "Claude (" + c.model + ")"
+}
+
+// Check verifies the claude CLI is installed.
+func (c *claudeCLI) Check(ctx context.Context) error {
+	cmd := proc.Command(ctx, "claude", "--version")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := "claude CLI not available"
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			msg += ": " + s
+		}
+
+		return errors.Environment(msg, err)
+	}
+
+	return nil
+}
+
+// args returns the arguments of `claude -p`, with --model if a model is set.
+func (c *claudeCLI) args(extra ...string) []string {
+	args := []string{"-p"}
+	if c.model != "" {
+		args = append(args, "--model", c.model)
+	}
+
+	return append(args, extra...)
+}
+
+// Generate invokes the claude CLI with the given prompt and returns its output.
+// If a model is set, it is passed via --model to the claude CLI.
+func (c *claudeCLI) Generate(ctx context.Context, prompt string) (string, error) {
+	cmd := proc.Command(ctx, "claude", c.args()...)
+	cmd.Stdin = strings.NewReader(prompt)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", claudeError(stderr.String(), err)
+	}
+
+	return stdout.String(), nil
+}
+
+// claudeError describes a failed claude run by its output. Failures the output shows to
+// be transient, such as an overloaded or rate-limited API, are marked as retryable.
+func claudeError(output string, err error) error {
+	const msg = "failed to generate notes with Claude"
+
+	output = strings.TrimSpace(output)
+
+	switch {
+	case retry.IsTransientMessage(output):
+		// Same message as below, with the output moved into the marked cause
+		cause := fmt.Errorf("%s", output)
+		if err != nil {
+			cause = fmt.Errorf("%s: %w", output, err)
+		}
+
+		return errors.Runtime(msg, retry.Retryable(cause, 0))
+	case output != "":
+		return errors.Runtime(msg+": "+output, err)
+	default:
+		return errors.Runtime(msg, err)
+	}
+}
+
+// claudeEvent is a line of `claude -p --output-format stream-json` output. Partial
+// messages carry text deltas; complete assistant messages also list tool calls; the
+// final result holds the text of the last turn.
+type claudeEvent struct {
+	Type  string `json:"type"`
+	Event struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+	} `json:"event"`
+	Message struct {
+		Content []struct {
+			Type  string         `json:"type"`
+			Text  string         `json:"text"`
+			Name  string         `json:"name"`
+			Input map[string]any `json:"input"`
+		} `json:"content"`
+	} `json:"message"`
+	IsError bool   `json:"is_error"`
+	Result  string `json:"result"`
+}
+
+// Stream runs the claude CLI with streaming JSON output, writing text to w as it arrives
+// and tool calls (such as the git commands the model runs) as separate lines.
+func (c *claudeCLI) Stream(ctx context.Context, prompt string, w io.Writer) (string, error) {
+	args := c.args("--output-format", "stream-json", "--verbose", "--include-partial-messages")
+
+	cmd := proc.Command(ctx, "claude", args...)
+	cmd.Stdin = strings.NewReader(prompt)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", errors.Runtime("failed to start claude CLI", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return "", errors.Runtime("failed to start claude CLI", err)
+	}
+
+	text, result, streamErr := readClaudeStream(stdout, w)
+
+	if err := cmd.Wait(); err != nil {
+		// A failed run often reports the API error in the result rather than on stderr
+		output := stderr.String()
+		if result != nil && result.IsError {
+			output += "\n" + result.Result
+		}
+
+		return "", claudeError(output, err)
+	}
+
+	if streamErr != nil {
+		return "", errors.Runtime("failed to read Claude output", streamErr)
+	}
+
+	if result != nil {
+		if result.IsError {
+			return "", claudeError(result.Result, nil)
+		}
+
+		return result.Result, nil
+	}
+
+	return text, nil
+}
+
+// readClaudeStream shows the events of a streamed claude run on w. It returns the text
+// of all turns and the final result event, if there was one.
+func readClaudeStream(r io.Reader, w io.Writer) (string, *claudeEvent, error) {
+	var (
+		text     strings.Builder
+		result   *claudeEvent
+		sawDelta bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+
+	for scanner.Scan() {
+		var ev claudeEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			// Not an event (e.g. a warning); keep reading
+			continue
+		}
+
+		switch ev.Type {
+		case "stream_event":
+			if ev.Event.Type == "content_block_delta" && ev.Event.Delta.Type == "text_delta" {
+				sawDelta = true
+
+				_, _ = io.WriteString(w, ev.Event.Delta.Text)
+			}
+		case "assistant":
+			for _, block := range ev.Message.Content {
+				switch block.Type {
+				case "text":
+					text.WriteString(block.Text)
+
+					// Without partial messages, show whole messages instead
+					if !sawDelta {
+						_, _ = io.WriteString(w, block.Text)
+					}
+				case "tool_use":
+					_, _ = fmt.Fprintf(w, "\n[%s]\n", toolSummary(block.Name, block.Input))
+				}
+			}
+		case "result":
+			result = &ev
+		}
+	}
+
+	// Drain the rest so the CLI does not block on a full pipe
+	_, _ = io.Copy(io.Discard, r)
+
+	return text.String(), result, scanner.Err()
+}
+
+// toolSummary describes a tool call in one line, e.g. "Bash: git show abc123".
+func toolSummary(name string, input map[string]any) string {
+	if command, ok := input["command"].(string); ok {
+		return name + ": " + command
+	}
+
+	return name
+}
@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/retry"
+)
+
+// Options control how generators created by NewChain handle transient failures.
+type Options struct {
+	// Retry is the policy for the transient failures of each model.
+	Retry retry.Policy
+	// OnFallback, if set, is called when a model gives up and the next one takes over.
+	OnFallback func(failed, next Generator, err error)
+}
+
+// NewChain creates the generator for the given backend and a comma-separated list of
+// models, such as "opus,sonnet,haiku". Each model retries transient failures (overload,
+// rate limiting, network errors) according to the retry policy; a model that keeps
+// failing hands the prompt to the next one. Fatal errors, such as a rejected API key,
+// end the chain at once.
+func NewChain(backend, models string, opts Options) (Generator, error) {
+	var chain []Generator
+
+	for _, model := range Models(models) {
+		g, err := New(backend, model)
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, &retrying{Generator: g, policy: opts.Retry, label: Label(backend, model)})
+	}
+
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+
+	return &fallback{generators: chain, onFallback: opts.OnFallback}, nil
+}
+
+// Models splits a comma-separated list of models. An empty list holds the default model "".
+func Models(models string) []string {
+	var list []string
+
+	for model := range strings.SplitSeq(models, ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			list = append(list, model)
+		}
+	}
+
+	if len(list) == 0 {
+		return []string{""}
+	}
+
+	return list
+}
+
+// Label identifies a backend and model, e.g. "anthropic/opus", or only the backend for
+// its default model, e.g. "claude".
+func Label(backend, model string) string {
+	if model == "" {
+		return backend
+	}
+
+	return backend + "/" + model
+}
+
+// GenerateLabeled is like g.Generate, but also returns the Label of the model that wrote
+// the output: for a chain of models, the one that succeeded. Generators not created by
+// NewChain are labeled by their name.
+func GenerateLabeled(ctx context.Context, g Generator, prompt string) (string, string, error) {
+	f, ok := g.(*fallback)
+	if !ok {
+		output, err := g.Generate(ctx, prompt)
+
+		return output, labelOf(g), err
+	}
+
+	var label string
+
+	output, err := f.run(ctx, func(g Generator) (string, error) {
+		label = labelOf(g)
+
+		return g.Generate(ctx, prompt)
+	})
+
+	return output, label, err
+}
+
+func labelOf(g Generator) string {
+	if r, ok := g.(*retrying); ok && r.label != "" {
+		return r.label
+	}
+
+	return g.Name()
+}
+
+// retrying repeats the calls of a generator that fail with transient errors.
+type retrying struct {
+	Generator
+
+	policy retry.Policy
+	// label is the Label of the backend and model.
+	label string
+}
+
+func (r *retrying) Generate(ctx context.Context, prompt string) (string, error) {
+	var output string
+
+	err := retry.Do(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+
+		output, err = r.Generator.Generate(ctx, prompt)
+
+		return err
+	})
+
+	return output, err
+}
+
+// Stream streams if the generator supports it. A repeated call streams the response
+// again from the start.
+func (r *retrying) Stream(ctx context.Context, prompt string, w io.Writer) (string, error) {
+	s, ok := r.Generator.(Streamer)
+	if !ok {
+		return r.Generate(ctx, prompt)
+	}
+
+	var output string
+
+	err := retry.Do(ctx, r.policy, func(ctx context.Context) error {
+		var err error
+
+		output, err = s.Stream(ctx, prompt, w)
+
+		return err
+	})
+
+	return output, err
+}
+
+// fallback tries its generators in order until one succeeds or fails with a fatal error.
+type fallback struct {
+	generators []Generator
+	onFallback func(failed, next Generator, err error)
+}
+
+// Name lists the models in the order they are tried.
+func (f *fallback) Name() string {
+	names := make([]string, 0, len(f.generators)-1)
+	for _, g := range f.generators[1:] {
+		names = append(names, g.Name())
+	}
+
+	return f.generators[0].Name() + " with fallback to " + strings.Join(names, ", ")
+}
+
+// Check verifies the first model only: the others use the same backend.
+func (f *fallback) Check(ctx context.Context) error {
+	return f.generators[0].Check(ctx)
+}
+
+func (f *fallback) Generate(ctx context.Context, prompt string) (string, error) {
+	return f.run(ctx, func(g Generator) (string, error) { return g.Generate(ctx, prompt) })
+}
+
+func (f *fallback) Stream(ctx context.Context, prompt string, w io.Writer) (string, error) {
+	return f.run(ctx, func(g Generator) (string, error) {
+		if s, ok := g.(Streamer); ok {
+			return s.Stream(ctx, prompt, w)
+		}
+
+		return g.Generate(ctx, prompt)
+	})
+}
+
+// run calls each generator in turn while the previous one gave up on a transient error.
+func (f *fallback) run(ctx context.Context, call func(g Generator) (string, error)) (string, error) {
+	last := len(f.generators) - 1
+
+	for i, g := range f.generators[:last] {
+		output, err := call(g)
+		if err == nil || ctx.Err() != nil || !retry.IsRetryable(err) {
+			return output, err
+		}
+
+		if f.onFallback != nil {
+			f.onFallback(g, f.generators[i+1], err)
+		}
+	}
+
+	return call(f.generators[last])
+}
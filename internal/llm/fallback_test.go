@@ -0,0 +1,223 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AndreyAkinshin/herald/internal/retry"
+)
+
+// fastRetry retries without noticeable waits.
+var fastRetry = retry.Policy{Attempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+// flakyGenerator fails with its errors, one per call, then returns its output.
+type flakyGenerator struct {
+	name   string
+	output string
+	errs   []error
+	calls  int
+}
+
+func (f *flakyGenerator) Name() string                { return f.name }
+func (f *flakyGenerator) Check(context.Context) error { return nil }
+
+func (f *flakyGenerator) Generate(context.Context, string) (string, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return "", f.errs[f.calls-1]
+	}
+
+	return f.output, nil
+}
+
+func overloaded() error { return retry.Retryable(fmt.Errorf("529 overloaded"), 0) }
+
+func TestModels(t *testing.T) {
+	tests := []struct {
+		models string
+		want   []string
+	}{
+		{"", []string{""}},
+		{"opus", []string{"opus"}},
+		{"opus, sonnet,,haiku ", []string{"opus", "sonnet", "haiku"}},
+	}
+
+	for _, tt := range tests {
+		if got := Models(tt.models); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Models(%q) = %q, want %q", tt.models, got, tt.want)
+		}
+	}
+}
+
+func TestNewChain(t *testing.T) {
+	g, err := NewChain(BackendClaude, "opus", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := g.(*retrying); !ok {
+		t.Errorf("got %T, want *retrying for a single model", g)
+	}
+
+	g, err = NewChain(BackendAnthropic, "opus,haiku", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "Anthropic API (claude-opus-4-1) with fallback to Anthropic API (claude-haiku-4-5)"
+	if g.Name() != want {
+		t.Errorf("Name() = %q, want %q", g.Name(), want)
+	}
+}
+
+func TestNewChain_invalid_model(t *testing.T) {
+	if _, err := NewChain(BackendOpenAI, " , ", Options{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestRetrying_retries_transient_errors(t *testing.T) {
+	g := &flakyGenerator{output: "notes", errs: []error{overloaded()}}
+
+	got, err := (&retrying{Generator: g, policy: fastRetry}).Generate(t.Context(), "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "notes" || g.calls != 2 {
+		t.Errorf("got %q after %d calls, want %q after 2", got, g.calls, "notes")
+	}
+}
+
+func TestFallback_moves_on_after_transient_errors(t *testing.T) {
+	first := &flakyGenerator{name: "opus", errs: []error{overloaded(), overloaded()}}
+	second := &flakyGenerator{name: "sonnet", output: "notes"}
+
+	var switched []string
+
+	f := &fallback{
+		generators: []Generator{
+			&retrying{Generator: first, policy: fastRetry},
+			&retrying{Generator: second, policy: fastRetry},
+		},
+		onFallback: func(failed, next Generator, _ error) {
+			switched = append(switched, failed.Name()+" -> "+next.Name())
+		},
+	}
+
+	got, err := f.Generate(t.Context(), "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "notes" {
+		t.Errorf("got %q, want %q", got, "notes")
+	}
+
+	if first.calls != 2 || len(switched) != 1 || switched[0] != "opus -> sonnet" {
+		t.Errorf("got %d calls of the first model and fallbacks %q", first.calls, switched)
+	}
+}
+
+func TestFallback_stops_on_fatal_error(t *testing.T) {
+	first := &flakyGenerator{name: "opus", errs: []error{fmt.Errorf("invalid API key")}}
+	second := &flakyGenerator{name: "sonnet", output: "notes"}
+
+	f := &fallback{generators: []Generator{first, second}}
+
+	if _, err := f.Generate(t.Context(), "prompt"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if second.calls != 0 {
+		t.Errorf("got %d calls of the second model, want 0", second.calls)
+	}
+}
+
+func TestGenerateLabeled(t *testing.T) {
+	first := &flakyGenerator{name: "opus", errs: []error{overloaded(), overloaded()}}
+	second := &flakyGenerator{name: "sonnet", output: "notes"}
+
+	f := &fallback{generators: []Generator{
+		&retrying{Generator: first, policy: fastRetry, label: Label(BackendAnthropic, "opus")},
+		&retrying{Generator: second, policy: fastRetry, label: Label(BackendAnthropic, "sonnet")},
+	}}
+
+	got, label, err := GenerateLabeled(t.Context(), f, "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "notes" || label != "anthropic/sonnet" {
+		t.Errorf("got %q from %q, want %q from anthropic/sonnet", got, label, "notes")
+	}
+
+	if _, label, _ := GenerateLabeled(t.Context(), second, "prompt"); label != "sonnet" {
+		t.Errorf("got label %q for a plain generator, want its name", label)
+	}
+}
+
+func TestAnthropic_generate_classifies_errors(t *testing.T) {
+	tests := []struct {
+		status    int
+		retryable bool
+	}{
+		{retry.StatusOverloaded, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "3")
+			http.Error(w, `{"error":"nope"}`, tt.status)
+		}))
+
+		_, err := newAnthropic(srv.URL, "secret", "").Generate(t.Context(), "prompt")
+
+		srv.Close()
+
+		if got := retry.IsRetryable(err); got != tt.retryable {
+			t.Errorf("status %d: retryable = %v, want %v (err: %v)", tt.status, got, tt.retryable, err)
+		}
+	}
+}
+
+func TestAnthropic_stream_overloaded_event_is_retryable(t *testing.T) {
+	srv := sseServer(t, "/v1/messages",
+		`data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+
+	_, err := newAnthropic(srv.URL, "secret", "").Stream(t.Context(), "prompt", nil)
+	if !retry.IsRetryable(err) {
+		t.Errorf("got %v, want a retryable error", err)
+	}
+}
+
+func TestClaudeError(t *testing.T) {
+	tests := []struct {
+		output    string
+		retryable bool
+		want      string
+	}{
+		{"API Error: 529 Overloaded", true, "failed to generate notes with Claude: API Error: 529 Overloaded"},
+		{"Invalid model name", false, "failed to generate notes with Claude: Invalid model name"},
+		{"", false, "failed to generate notes with Claude"},
+	}
+
+	for _, tt := range tests {
+		err := claudeError(tt.output, nil)
+
+		if got := retry.IsRetryable(err); got != tt.retryable {
+			t.Errorf("claudeError(%q): retryable = %v, want %v", tt.output, got, tt.retryable)
+		}
+
+		if err.Error() != tt.want {
+			t.Errorf("claudeError(%q) = %q, want %q", tt.output, err.Error(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/retry"
+)
+
+// maxErrorBody limits how much of an error response is included in messages.
+const maxErrorBody = 512
+
+// httpClient has no timeout of its own: a generation is bounded by its context, which
+// carries the generate phase timeout (--timeout generate=...). A non-streaming response
+// only starts once the whole generation is done, so even its headers can take minutes.
+//
+//nolint:gochecknoglobals // shared client reuses connections across requests
+var httpClient = &http.Client{}
+
+// doJSON sends a request with an optional JSON body and decodes a JSON response into out.
+// Non-2xx responses are returned as errors that include the (truncated) response body.
+// Network failures and responses worth retrying are marked with retry.Retryable.
+func doJSON(ctx context.Context, method, url string, headers map[string]string, body, out any) error {
+	var reader io.Reader
+
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// Network failures are transient; retries stop anyway once ctx is done
+		return retry.Retryable(err, 0)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return retry.Retryable(fmt.Errorf("read response: %w", err), 0)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return statusError(resp, data)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+// statusError describes a non-2xx response with its (truncated) body, marked as transient
+// for rate limiting, overload and server errors.
+func statusError(resp *http.Response, data []byte) error {
+	err := fmt.Errorf("%s: %s", resp.Status, truncate(strings.TrimSpace(string(data)), maxErrorBody))
+
+	return retry.ForResponse(err, resp)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n] + "..."
+}
+
+// maxStreamLine bounds a single line of a streamed response.
+const maxStreamLine = 1 << 20
+
+// doStream sends a JSON request and calls onLine with every non-empty line of the
+// response as it arrives, for server-sent events and newline-delimited JSON.
+// Non-2xx responses are returned as errors like in doJSON.
+func doStream(
+	ctx context.Context, url string, headers map[string]string, body any, onLine func(line string) error,
+) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// Network failures are transient; retries stop anyway once ctx is done
+		return retry.Retryable(err, 0)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
+
+		return statusError(resp, data)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if err := onLine(line); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return retry.Retryable(fmt.Errorf("read response: %w", err), 0)
+	}
+
+	return nil
+}
+
+// sseData returns the payload of a server-sent event "data:" line.
+func sseData(line string) (string, bool) {
+	data, ok := strings.CutPrefix(line, "data:")
+
+	return strings.TrimSpace(data), ok
+}
@@ -0,0 +1,88 @@
+// Package llm provides language model backends for generating release notes.
+package llm
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+// Supported backend names (values of the --backend flag).
+const (
+	BackendClaude    = "claude"
+	BackendAnthropic = "anthropic"
+	BackendOpenAI    = "openai"
+	BackendOllama    = "ollama"
+	// BackendTemplate renders notes from the commits without a model; it has no Generator.
+	BackendTemplate = "template"
+)
+
+// Backends lists all supported backend names in display order.
+var Backends = []string{BackendClaude, BackendAnthropic, BackendOpenAI, BackendOllama, BackendTemplate}
+
+// Generator turns a prompt into release notes text.
+type Generator interface {
+	// Name returns a human-readable name used in progress messages.
+	Name() string
+	// Check verifies the backend is usable (binary installed, credentials set, endpoint reachable).
+	Check(ctx context.Context) error
+	// Generate sends the prompt to the model and returns the raw response.
+	Generate(ctx context.Context, prompt string) (string, error)
+}
+
+// New creates the generator for the given backend.
+// An empty backend selects the claude CLI. Endpoints and credentials for the
+// HTTP backends are read from the environment:
+//   - anthropic: ANTHROPIC_API_KEY, ANTHROPIC_BASE_URL
+//   - openai:    OPENAI_API_KEY, OPENAI_BASE_URL
+//   - ollama:    OLLAMA_HOST
+func New(backend, model string) (Generator, error) {
+	switch backend {
+	case "", BackendClaude:
+		return &claudeCLI{model: model}, nil
+	case BackendAnthropic:
+		return newAnthropic(
+			envOr("ANTHROPIC_BASE_URL", anthropicDefaultURL),
+			os.Getenv("ANTHROPIC_API_KEY"),
+			model,
+		), nil
+	case BackendOpenAI:
+		if model == "" {
+			return nil, errors.Config("--model is required for the openai backend")
+		}
+
+		baseURL := envOr("OPENAI_BASE_URL", openAIDefaultURL)
+
+		return newOpenAI(baseURL, os.Getenv("OPENAI_API_KEY"), model, baseURL != openAIDefaultURL), nil
+	case BackendOllama:
+		if model == "" {
+			return nil, errors.Config("--model is required for the ollama backend")
+		}
+
+		return newOllama(ollamaURL(os.Getenv("OLLAMA_HOST")), model), nil
+	case BackendTemplate:
+		return nil, errors.Config("the template backend does not use a model")
+	default:
+		return nil, errors.Config("unknown backend " + backend + " (supported: " + strings.Join(Backends, ", ") + ")")
+	}
+}
+
+// GenerateNotes runs the generator and strips conversational preamble from the result.
+func GenerateNotes(ctx context.Context, g Generator, prompt string) (string, error) {
+	output, err := g.Generate(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	return stripPreamble(output), nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return fallback
+}
@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew_default_is_claude_cli(t *testing.T) {
+	g, err := New("", "haiku")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := g.(*claudeCLI); !ok {
+		t.Errorf("got %T, want *claudeCLI", g)
+	}
+}
+
+func TestNew_unknown_backend(t *testing.T) {
+	if _, err := New("gpt-cli", ""); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestNew_openai_requires_model(t *testing.T) {
+	if _, err := New(BackendOpenAI, ""); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestNew_anthropic_resolves_alias(t *testing.T) {
+	g, err := New(BackendAnthropic, "haiku")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := g.(*anthropicAPI).model; got != "claude-haiku-4-5" {
+		t.Errorf("model = %q, want %q", got, "claude-haiku-4-5")
+	}
+}
+
+func TestAnthropic_check_requires_key(t *testing.T) {
+	if err := newAnthropic(anthropicDefaultURL, "", "").Check(t.Context()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestAnthropic_generate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %q, want /v1/messages", r.URL.Path)
+		}
+
+		if got := r.Header.Get("x-api-key"); got != "secret" {
+			t.Errorf("x-api-key = %q, want %q", got, "secret")
+		}
+
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+
+		if req.Messages[0].Content != "prompt" {
+			t.Errorf("content = %q, want %q", req.Messages[0].Content, "prompt")
+		}
+
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"## Features"},{"type":"text","text":"\n- x"}]}`))
+	}))
+	defer srv.Close()
+
+	got, err := newAnthropic(srv.URL, "secret", "").Generate(t.Context(), "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "## Features\n- x" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestAnthropic_generate_http_error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"overloaded"}`, http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if _, err := newAnthropic(srv.URL, "secret", "").Generate(t.Context(), "prompt"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestOpenAI_generate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %q, want /v1/chat/completions", r.URL.Path)
+		}
+
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"notes"}}]}`))
+	}))
+	defer srv.Close()
+
+	got, err := newOpenAI(srv.URL+"/v1/", "secret", "gpt", false).Generate(t.Context(), "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "notes" {
+		t.Errorf("got %q, want %q", got, "notes")
+	}
+}
+
+func TestOpenAI_check_key_optional_for_custom_endpoint(t *testing.T) {
+	if err := newOpenAI("http://localhost:8000/v1", "", "m", true).Check(t.Context()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := newOpenAI(openAIDefaultURL, "", "m", false).Check(t.Context()); err == nil {
+		t.Error("expected error for default endpoint without key")
+	}
+}
+
+func TestOllama_generate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			_, _ = w.Write([]byte(`{"version":"0.5.0"}`))
+		case "/api/chat":
+			var req ollamaRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+
+			if req.Stream {
+				t.Error("expected stream to be disabled")
+			}
+
+			_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"local notes"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	g := newOllama(srv.URL, "llama3")
+
+	if err := g.Check(t.Context()); err != nil {
+		t.Fatalf("Check() error: %v", err)
+	}
+
+	got, err := g.Generate(t.Context(), "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "local notes" {
+		t.Errorf("got %q, want %q", got, "local notes")
+	}
+}
+
+func TestOllamaURL(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"", ollamaDefaultURL},
+		{"0.0.0.0:11434", "http://0.0.0.0:11434"},
+		{"https://ollama.internal", "https://ollama.internal"},
+	}
+
+	for _, tt := range tests {
+		if got := ollamaURL(tt.host); got != tt.want {
+			t.Errorf("ollamaURL(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+type fakeGenerator struct{ output string }
+
+func (f fakeGenerator) Name() string                                     { return "fake" }
+func (f fakeGenerator) Check(context.Context) error                      { return nil }
+func (f fakeGenerator) Generate(context.Context, string) (string, error) { return f.output, nil }
+
+func TestGenerateNotes_strips_preamble(t *testing.T) {
+	got, err := GenerateNotes(t.Context(), fakeGenerator{output: "# Release v1.0\n\n## Features"}, "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "## Features" {
+		t.Errorf("got %q, want %q", got, "## Features")
+	}
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+const ollamaDefaultURL = "http://localhost:11434"
+
+// ollamaAPI generates notes via a local or on-prem Ollama server.
+type ollamaAPI struct {
+	baseURL string
+	model   string
+}
+
+func newOllama(baseURL, model string) *ollamaAPI {
+	return &ollamaAPI{baseURL: strings.TrimRight(baseURL, "/"), model: model}
+}
+
+// ollamaURL normalizes OLLAMA_HOST, which is commonly set without a scheme (e.g. "0.0.0.0:11434").
+func ollamaURL(host string) string {
+	if host == "" {
+		return ollamaDefaultURL
+	}
+
+	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
+		return "http://" + host
+	}
+
+	return host
+}
+
+func (o *ollamaAPI) Name() string { return "Ollama (" + o.model + ")" }
+
+// Check verifies the Ollama server is reachable.
+func (o *ollamaAPI) Check(ctx context.Context) error {
+	if err := doJSON(ctx, http.MethodGet, o.baseURL+"/api/version", nil, nil, nil); err != nil {
+		return errors.Environment("Ollama not reachable at "+o.baseURL, err)
+	}
+
+	return nil
+}
+
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type ollamaResponse struct {
+	Message chatMessage `json:"message"`
+	// Error is set in a streamed line when generation fails midway.
+	Error string `json:"error"`
+}
+
+func (o *ollamaAPI) request(prompt string) ollamaRequest {
+	return ollamaRequest{
+		Model:    o.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	}
+}
+
+// Generate sends the prompt to the chat endpoint with streaming disabled.
+func (o *ollamaAPI) Generate(ctx context.Context, prompt string) (string, error) {
+	var resp ollamaResponse
+	if err := doJSON(ctx, http.MethodPost, o.baseURL+"/api/chat", nil, o.request(prompt), &resp); err != nil {
+		return "", errors.Runtime("failed to generate notes with Ollama", err)
+	}
+
+	return resp.Message.Content, nil
+}
+
+// Stream enables streaming, in which Ollama sends one JSON object per line, and writes
+// the message content to w as it arrives.
+func (o *ollamaAPI) Stream(ctx context.Context, prompt string, w io.Writer) (string, error) {
+	req := o.request(prompt)
+	req.Stream = true
+
+	var b strings.Builder
+
+	err := doStream(ctx, o.baseURL+"/api/chat", nil, req, func(line string) error {
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return fmt.Errorf("%s", chunk.Error)
+		}
+
+		b.WriteString(chunk.Message.Content)
+		_, _ = io.WriteString(w, chunk.Message.Content)
+
+		return nil
+	})
+	if err != nil {
+		return "", errors.Runtime("failed to generate notes with Ollama", err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,132 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/AndreyAkinshin/herald/internal/errors"
+)
+
+const openAIDefaultURL = "https://api.openai.com/v1"
+
+// openAIAPI generates notes via an OpenAI-compatible chat completions endpoint.
+// Besides OpenAI itself, this covers on-prem servers such as vLLM or LiteLLM.
+type openAIAPI struct {
+	baseURL string
+	apiKey  string
+	model   string
+	// keyOptional is set for custom endpoints, which often run without authentication.
+	keyOptional bool
+}
+
+func newOpenAI(baseURL, apiKey, model string, keyOptional bool) *openAIAPI {
+	return &openAIAPI{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      apiKey,
+		model:       model,
+		keyOptional: keyOptional,
+	}
+}
+
+func (o *openAIAPI) Name() string { return "OpenAI-compatible API (" + o.model + ")" }
+
+// Check verifies an API key is configured when the endpoint requires one.
+func (o *openAIAPI) Check(context.Context) error {
+	if o.apiKey == "" && !o.keyOptional {
+		return errors.Environment("OPENAI_API_KEY is not set", nil)
+	}
+
+	return nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// openAIChunk is a server-sent event of a streamed response.
+type openAIChunk struct {
+	Choices []struct {
+		Delta chatMessage `json:"delta"`
+	} `json:"choices"`
+}
+
+func (o *openAIAPI) request(prompt string) openAIRequest {
+	return openAIRequest{
+		Model:    o.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	}
+}
+
+func (o *openAIAPI) headers() map[string]string {
+	headers := map[string]string{}
+	if o.apiKey != "" {
+		headers["Authorization"] = "Bearer " + o.apiKey
+	}
+
+	return headers
+}
+
+// Generate sends the prompt as a single user message and returns the first choice.
+func (o *openAIAPI) Generate(ctx context.Context, prompt string) (string, error) {
+	var resp openAIResponse
+	err := doJSON(ctx, http.MethodPost, o.baseURL+"/chat/completions", o.headers(), o.request(prompt), &resp)
+	if err != nil {
+		return "", errors.Runtime("failed to generate notes with OpenAI-compatible API", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", errors.Runtime("OpenAI-compatible API returned no choices", nil)
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+// Stream requests server-sent events and writes the content deltas of the first choice
+// to w as they arrive.
+func (o *openAIAPI) Stream(ctx context.Context, prompt string, w io.Writer) (string, error) {
+	req := o.request(prompt)
+	req.Stream = true
+
+	var b strings.Builder
+
+	err := doStream(ctx, o.baseURL+"/chat/completions", o.headers(), req, func(line string) error {
+		data, ok := sseData(line)
+		if !ok || data == "[DONE]" {
+			return nil
+		}
+
+		var chunk openAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+
+		if len(chunk.Choices) > 0 {
+			b.WriteString(chunk.Choices[0].Delta.Content)
+			_, _ = io.WriteString(w, chunk.Choices[0].Delta.Content)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", errors.Runtime("failed to generate notes with OpenAI-compatible API", err)
+	}
+
+	return b.String(), nil
+}
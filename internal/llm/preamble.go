@@ -1,61 +1,8 @@
-// Package claude provides Claude CLI wrapper functions.
-package claude
+package llm
 
-import (
-	"bytes"
-	"os/exec"
-	"strings"
+import "strings"
 
-	"github.com/AndreyAkinshin/herald/internal/errors"
-)
-
-// CheckClaudeAvailable verifies the claude CLI is installed.
-func CheckClaudeAvailable() error {
-	cmd := exec.Command("claude", "--version")
-
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		msg := "claude CLI not available"
-		if s := strings.TrimSpace(stderr.String()); s != "" {
-			msg += ": " + s
-		}
-
-		return errors.Environment(msg, err)
-	}
-
-	return nil
-}
-
-// GenerateNotes invokes Claude with the given prompt and returns the generated notes.
-// If model is non-empty, it is passed via --model to the claude CLI.
-func GenerateNotes(prompt string, model string) (string, error) {
-	args := []string{"-p"}
-	if model != "" {
-		args = append(args, "--model", model)
-	}
-
-	cmd := exec.Command("claude", args...)
-	cmd.Stdin = strings.NewReader(prompt)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		msg := "failed to generate notes with Claude"
-		if s := strings.TrimSpace(stderr.String()); s != "" {
-			msg += ": " + s
-		}
-
-		return "", errors.Runtime(msg, err)
-	}
-
-	return stripPreamble(stdout.String()), nil
-}
-
-// stripPreamble removes unwanted leading content that Claude sometimes adds:
+// stripPreamble removes unwanted leading content that models sometimes add:
 //   - A leading H1 heading (e.g. "# Release Notes for v1.2.0")
 //   - Conversational preamble ending with ":" (e.g. "Here are the release notes:")
 //     optionally followed by a thematic break ("---")
@@ -0,0 +1,29 @@
+package llm
+
+import (
+	"context"
+	"io"
+)
+
+// Streamer is implemented by generators that can show the response while it is produced.
+type Streamer interface {
+	// Stream is like Generate, but also writes the text to w as it arrives.
+	Stream(ctx context.Context, prompt string, w io.Writer) (string, error)
+}
+
+// StreamNotes is like GenerateNotes, but writes the response to w as it arrives when the
+// generator supports streaming. The returned notes are the complete response with the
+// conversational preamble stripped, as with GenerateNotes.
+func StreamNotes(ctx context.Context, g Generator, prompt string, w io.Writer) (string, error) {
+	s, ok := g.(Streamer)
+	if !ok {
+		return GenerateNotes(ctx, g, prompt)
+	}
+
+	output, err := s.Stream(ctx, prompt, w)
+	if err != nil {
+		return "", err
+	}
+
+	return stripPreamble(output), nil
+}
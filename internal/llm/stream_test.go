@@ -0,0 +1,217 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseServer serves the given lines as the streamed response of path.
+func sseServer(t *testing.T, path string, lines ...string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			t.Errorf("expected a streaming request (err: %v)", err)
+		}
+
+		for _, line := range lines {
+			_, _ = w.Write([]byte(line + "\n"))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestAnthropic_stream(t *testing.T) {
+	srv := sseServer(t, "/v1/messages",
+		"event: message_start",
+		`data: {"type":"message_start","message":{}}`,
+		"",
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"## Features"}}`,
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"\n- x"}}`,
+		`data: {"type":"message_stop"}`,
+	)
+
+	var shown strings.Builder
+
+	got, err := newAnthropic(srv.URL, "secret", "").Stream(t.Context(), "prompt", &shown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "## Features\n- x" || shown.String() != got {
+		t.Errorf("got %q, shown %q", got, shown.String())
+	}
+}
+
+func TestAnthropic_stream_error_event(t *testing.T) {
+	srv := sseServer(t, "/v1/messages",
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"## Feat"}}`,
+		`data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+	)
+
+	_, err := newAnthropic(srv.URL, "secret", "").Stream(t.Context(), "prompt", &strings.Builder{})
+	if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Fatalf("got %v, want overloaded error", err)
+	}
+}
+
+func TestOpenAI_stream(t *testing.T) {
+	srv := sseServer(t, "/v1/chat/completions",
+		`data: {"choices":[{"delta":{"role":"assistant","content":""}}]}`,
+		`data: {"choices":[{"delta":{"content":"no"}}]}`,
+		`data: {"choices":[{"delta":{"content":"tes"}}]}`,
+		`data: [DONE]`,
+	)
+
+	got, err := newOpenAI(srv.URL+"/v1", "secret", "gpt", false).Stream(t.Context(), "prompt", &strings.Builder{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "notes" {
+		t.Errorf("got %q, want %q", got, "notes")
+	}
+}
+
+func TestOllama_stream(t *testing.T) {
+	srv := sseServer(t, "/api/chat",
+		`{"message":{"role":"assistant","content":"local "},"done":false}`,
+		`{"message":{"role":"assistant","content":"notes"},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true}`,
+	)
+
+	got, err := newOllama(srv.URL, "llama3").Stream(t.Context(), "prompt", &strings.Builder{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "local notes" {
+		t.Errorf("got %q, want %q", got, "local notes")
+	}
+}
+
+func TestReadClaudeStream(t *testing.T) {
+	events := strings.Join([]string{
+		`{"type":"system","subtype":"init"}`,
+		`{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"Let me check."}}}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Let me check."},` +
+			`{"type":"tool_use","name":"Bash","input":{"command":"git show abc"}}]}}`,
+		`{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"## Features"}}}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"## Features"}]}}`,
+		`{"type":"result","subtype":"success","is_error":false,"result":"## Features"}`,
+	}, "\n")
+
+	var shown strings.Builder
+
+	text, result, err := readClaudeStream(strings.NewReader(events), &shown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result == nil || result.Result != "## Features" {
+		t.Errorf("result = %+v", result)
+	}
+
+	if text != "Let me check.## Features" {
+		t.Errorf("text = %q", text)
+	}
+
+	if want := "Let me check.\n[Bash: git show abc]\n## Features"; shown.String() != want {
+		t.Errorf("shown %q, want %q", shown.String(), want)
+	}
+}
+
+func TestReadClaudeStream_without_partial_messages(t *testing.T) {
+	events := `{"type":"assistant","message":{"content":[{"type":"text","text":"## Fixes"}]}}`
+
+	var shown strings.Builder
+
+	text, result, err := readClaudeStream(strings.NewReader(events), &shown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result != nil || text != "## Fixes" || shown.String() != "## Fixes" {
+		t.Errorf("text %q, shown %q, result %+v", text, shown.String(), result)
+	}
+}
+
+type fakeStreamer struct {
+	fakeGenerator
+}
+
+func (f fakeStreamer) Stream(_ context.Context, _ string, w io.Writer) (string, error) {
+	_, _ = io.WriteString(w, f.output)
+
+	return f.output, nil
+}
+
+func TestStreamNotes_strips_preamble(t *testing.T) {
+	output := "Here are the notes:\n\n## Features"
+
+	var shown strings.Builder
+
+	got, err := StreamNotes(t.Context(), fakeStreamer{fakeGenerator{output: output}}, "prompt", &shown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "## Features" || shown.String() != output {
+		t.Errorf("got %q, shown %q", got, shown.String())
+	}
+}
+
+func TestStreamNotes_without_streaming(t *testing.T) {
+	var shown strings.Builder
+
+	got, err := StreamNotes(t.Context(), fakeGenerator{output: "## Features"}, "prompt", &shown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "## Features" || shown.Len() != 0 {
+		t.Errorf("got %q, shown %q", got, shown.String())
+	}
+}
+
+func TestAnthropic_stream_is_bounded_by_context_only(t *testing.T) {
+	if httpClient.Timeout != 0 {
+		t.Fatalf("httpClient.Timeout = %v, want none: the generate phase timeout bounds requests", httpClient.Timeout)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"## Feat"}}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := newAnthropic(srv.URL, "secret", "").Stream(ctx, "prompt", io.Discard); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if ctx.Err() == nil {
+		t.Error("stream ended before its context")
+	}
+}
@@ -0,0 +1,159 @@
+// Package notes renders release notes from commits with a Go template, without a
+// language model. The result is deterministic: the same commits give the same notes.
+package notes
+
+import (
+	"bytes"
+	_ "embed"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/AndreyAkinshin/herald/internal/git"
+)
+
+// Data holds the values rendered into the notes.
+type Data struct {
+	Tag     string
+	PrevTag string
+	// Unreleased marks a preview of changes not yet tagged.
+	Unreleased bool
+	Commits    []git.Commit
+}
+
+// Item is one change in the notes.
+type Item struct {
+	Hash        string
+	Scope       string
+	Description string
+	// PR is the pull request reference, e.g. "#123", if the commit mentions one.
+	PR     string
+	Author string
+	// BreakingNote is the text of the BREAKING CHANGE footer, if any.
+	BreakingNote string
+}
+
+// Ref returns the pull request reference, or the abbreviated hash if there is none.
+func (i Item) Ref() string {
+	if i.PR != "" {
+		return i.PR
+	}
+
+	return i.Hash[:min(len(i.Hash), 7)]
+}
+
+// Section is a heading with its changes.
+type Section struct {
+	Title string
+	Items []Item
+}
+
+// view is the template data: the commits sorted into sections.
+type view struct {
+	Data
+	Breaking []Item
+	Sections []Section
+}
+
+// otherChanges is the section of commits without a known Conventional Commits type.
+const otherChanges = "Other Changes"
+
+// sectionTitles are the sections in display order.
+var sectionTitles = []string{"Features", "Improvements", "Bug Fixes", "Documentation", "Internal", otherChanges}
+
+// typeSections maps Conventional Commits types to sections; other types go to otherChanges.
+var typeSections = map[string]string{
+	"feat":     "Features",
+	"perf":     "Improvements",
+	"fix":      "Bug Fixes",
+	"docs":     "Documentation",
+	"refactor": "Internal",
+	"test":     "Internal",
+	"build":    "Internal",
+	"ci":       "Internal",
+	"style":    "Internal",
+	"chore":    "Internal",
+}
+
+var (
+	// trailingPR matches a pull request suffix such as " (#123)", added by squash merges.
+	trailingPR = regexp.MustCompile(`\s*\((#\d+)\)$`)
+	// mergePR matches the subject of a GitHub pull request merge commit.
+	mergePR = regexp.MustCompile(`^Merge pull request (#\d+) from \S+`)
+	// authorName strips the email from "Name <email>".
+	authorName = regexp.MustCompile(`\s*<[^>]*>$`)
+)
+
+//go:embed notes.tmpl
+var notesText string
+
+var notesTemplate = template.Must(template.New("notes").Parse(notesText))
+
+// Render creates release notes listing the commits by section, breaking changes first.
+func Render(data Data) string {
+	v := view{Data: data}
+	bySection := map[string][]Item{}
+
+	for _, c := range data.Commits {
+		item, section := classify(c)
+
+		if c.Conventional != nil && c.Conventional.Breaking {
+			v.Breaking = append(v.Breaking, item)
+
+			continue
+		}
+
+		bySection[section] = append(bySection[section], item)
+	}
+
+	for _, title := range sectionTitles {
+		if items := bySection[title]; len(items) > 0 {
+			v.Sections = append(v.Sections, Section{Title: title, Items: items})
+		}
+	}
+
+	var buf bytes.Buffer
+
+	// Template is validated at init via template.Must; execution only fails
+	// on write errors to an in-memory buffer, which cannot happen in practice.
+	_ = notesTemplate.Execute(&buf, v)
+
+	return strings.TrimSpace(buf.String()) + "\n"
+}
+
+// classify turns a commit into an item and picks its section.
+func classify(c git.Commit) (Item, string) {
+	item := Item{
+		Hash:        c.Hash,
+		Description: c.Subject(),
+		Author:      authorName.ReplaceAllString(c.Author, ""),
+	}
+
+	section := otherChanges
+
+	if cc := c.Conventional; cc != nil {
+		item.BreakingNote = cc.BreakingNote
+	}
+
+	if cc := c.Conventional; cc != nil && cc.Type != "" {
+		item.Scope, item.Description = cc.Scope, cc.Description
+
+		if s, ok := typeSections[cc.Type]; ok {
+			section = s
+		}
+	} else if m := mergePR.FindStringSubmatch(item.Description); m != nil {
+		// The pull request title is the first line of the merge commit body
+		item.PR = m[1]
+
+		if _, body, ok := strings.Cut(c.Message, "\n"); ok && strings.TrimSpace(body) != "" {
+			item.Description, _, _ = strings.Cut(strings.TrimSpace(body), "\n")
+		}
+	}
+
+	if m := trailingPR.FindStringSubmatch(item.Description); m != nil {
+		item.PR = m[1]
+		item.Description = strings.TrimSuffix(item.Description, m[0])
+	}
+
+	return item, section
+}
@@ -0,0 +1,74 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/herald/internal/git"
+)
+
+func commits(messages ...string) []git.Commit {
+	res := make([]git.Commit, len(messages))
+	for i, m := range messages {
+		res[i] = git.Commit{Hash: "abcdef123456", Author: "Jane Doe <jane@example.com>", Message: m,
+			Conventional: git.ParseConventional(m)}
+	}
+
+	return res
+}
+
+func TestRender(t *testing.T) {
+	got := Render(Data{Tag: "v2.0.0", PrevTag: "v1.0.0", Commits: commits(
+		"fix: handle empty body",
+		"feat(api): add export (#12)",
+		"refactor!: drop the v1 API\n\nBREAKING CHANGE: use /v2 instead",
+		"Merge pull request #40 from jane/dark-mode\n\nAdd dark mode",
+		"Update README",
+	)})
+
+	want := `v2.0.0 includes 5 changes since v1.0.0.
+
+## Breaking Changes
+
+- drop the v1 API (abcdef1, Jane Doe)
+  use /v2 instead
+
+## Features
+
+- **api:** add export (#12, Jane Doe)
+
+## Bug Fixes
+
+- handle empty body (abcdef1, Jane Doe)
+
+## Other Changes
+
+- Add dark mode (#40, Jane Doe)
+- Update README (abcdef1, Jane Doe)
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRender_breaking_footer_without_type(t *testing.T) {
+	got := Render(Data{Tag: "v2.0.0", Commits: commits("Remove the v1 API (#7)\n\nBREAKING CHANGE: use /v2 instead")})
+
+	if !strings.Contains(got, "## Breaking Changes\n\n- Remove the v1 API (#7, Jane Doe)\n  use /v2 instead\n") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestRender_unreleased(t *testing.T) {
+	got := Render(Data{Tag: "HEAD", PrevTag: "v1.0.0", Unreleased: true, Commits: commits("docs: fix typo")})
+
+	if !strings.HasPrefix(got, "The next release includes 1 change since v1.0.0.\n\n## Documentation\n") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestRender_no_commits(t *testing.T) {
+	if got := Render(Data{Tag: "v1.0.0"}); got != "v1.0.0 includes no changes.\n" {
+		t.Errorf("got %q", got)
+	}
+}
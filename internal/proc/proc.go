@@ -0,0 +1,24 @@
+// Package proc runs external commands in their own process group, so that stopping
+// a command also stops everything it started.
+package proc
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// waitDelay is how long a stopped command may keep its output open before Wait gives up.
+const waitDelay = 5 * time.Second
+
+// Command is like exec.CommandContext, but when ctx is done it kills the whole process
+// group of the command rather than only the command. Use it for every external command:
+// claude runs tools, and git can start hooks, pagers or helpers of its own.
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	newGroup(cmd)
+	cmd.Cancel = func() error { return killGroup(cmd) }
+	cmd.WaitDelay = waitDelay
+
+	return cmd
+}
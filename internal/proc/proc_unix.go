@@ -0,0 +1,17 @@
+//go:build !windows
+
+package proc
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func newGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killGroup(cmd *exec.Cmd) error {
+	// The group id is the pid of its leader; a negative pid signals the whole group
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
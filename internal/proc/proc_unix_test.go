@@ -0,0 +1,37 @@
+//go:build !windows
+
+package proc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCommand_kills_process_group(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+
+	// The background sleep inherits stdout: Wait only returns early if it is killed too
+	cmd := Command(ctx, "sh", "-c", "sleep 30 & wait")
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	start := time.Now()
+
+	if err := cmd.Wait(); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if elapsed := time.Since(start); elapsed >= waitDelay {
+		t.Errorf("Wait took %v, want the child of sh to be killed as well", elapsed)
+	}
+}
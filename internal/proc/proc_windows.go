@@ -0,0 +1,18 @@
+//go:build windows
+
+package proc
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+func newGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+func killGroup(cmd *exec.Cmd) error {
+	// taskkill /T stops the process together with the processes it started
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
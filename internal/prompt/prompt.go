@@ -4,30 +4,172 @@ package prompt
 import (
 	"bytes"
 	_ "embed"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
 	"text/template"
+	"unicode/utf8"
 )
 
-type templateData struct {
-	TargetTag     string
-	PrevTag       string
+// DefaultSections are the groups changes are sorted into unless configured otherwise.
+var DefaultSections = []string{
+	"Breaking Changes", "Features", "Improvements", "Bug Fixes", "Documentation", "Internal",
+}
+
+// Data holds the values rendered into the prompt.
+type Data struct {
+	TargetTag string
+	PrevTag   string
+	// Until ends the commit range when it is not TargetTag.
+	Until         string
 	CommitDetails string
-	Instructions  string
+	// Diffs marks commit details that include the patches of the commits.
+	Diffs bool
+	// Tools marks a model that can run git commands in the repository, such as the claude CLI;
+	// other models are told to rely only on the prompt.
+	Tools        bool
+	Instructions string
+	// Sections are the groups to sort changes into; DefaultSections if empty.
+	Sections []string
+	// Unreleased marks a preview of changes not yet tagged; TargetTag is then a ref such as HEAD.
+	Unreleased bool
+	// Component is the monorepo component being released, if any.
+	Component string
+	// Paths are the component's pathspec globs the commits were limited to.
+	Paths []string
+	// Changes are the commits whose messages follow Conventional Commits.
+	Changes []Change
+	// Summaries replace the commit details when the commits were summarized first,
+	// because they did not fit in one prompt or to reuse cached summaries.
+	Summaries []Summary
+}
+
+// Summary is the summary of one commit, from the model or the cache.
+type Summary struct {
+	Hash string
+	Text string
+}
+
+// Short returns the abbreviated commit hash.
+func (s Summary) Short() string {
+	return s.Hash[:min(len(s.Hash), 7)]
+}
+
+// BatchData holds the values rendered into the prompt summarizing one batch of commits.
+type BatchData struct {
+	TargetTag  string
+	Unreleased bool
+	Component  string
+	// Batch is the number of the batch, starting at 1, out of Batches.
+	Batch         int
+	Batches       int
+	CommitDetails string
+	// Diffs marks commit details that include the patches of the commits.
+	Diffs bool
+}
+
+// Change is a commit classified by its Conventional Commits header.
+type Change struct {
+	Hash string
+	// Type is empty for a breaking change whose subject does not declare a type.
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+	// BreakingNote is the text of the BREAKING CHANGE footer, if any.
+	BreakingNote string
+}
+
+// Short returns the abbreviated commit hash.
+func (c Change) Short() string {
+	return c.Hash[:min(len(c.Hash), 7)]
+}
+
+// Group is the changes of one Conventional Commits type.
+type Group struct {
+	Title   string
+	Changes []Change
+}
+
+// typeTitles are the headings of the well-known Conventional Commits types, in display order.
+var typeTitles = []struct{ Type, Title string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"style", "Style"},
+	{"chore", "Chores"},
+	{"revert", "Reverts"},
+}
+
+// Groups returns the changes grouped by type: well-known types first, then other
+// types alphabetically, titled by the type itself. Changes without a type are only
+// listed among the breaking ones.
+func (d Data) Groups() []Group {
+	byType := map[string][]Change{}
+	for _, c := range d.Changes {
+		if c.Type != "" {
+			byType[c.Type] = append(byType[c.Type], c)
+		}
+	}
+
+	var groups []Group
+
+	for _, t := range typeTitles {
+		if changes, ok := byType[t.Type]; ok {
+			groups = append(groups, Group{Title: t.Title + " (" + t.Type + ")", Changes: changes})
+			delete(byType, t.Type)
+		}
+	}
+
+	for _, t := range slices.Sorted(maps.Keys(byType)) {
+		groups = append(groups, Group{Title: t, Changes: byType[t]})
+	}
+
+	return groups
+}
+
+// Breaking returns the changes marked as breaking.
+func (d Data) Breaking() []Change {
+	var breaking []Change
+
+	for _, c := range d.Changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+
+	return breaking
 }
 
 //go:embed prompt.tmpl
 var promptText string
 
-var promptTemplate = template.Must(template.New("prompt").Parse(promptText))
+var promptTemplate = template.Must(template.New("prompt").
+	Funcs(template.FuncMap{"join": strings.Join, "inc": func(i int) int { return i + 1 }}).
+	Parse(promptText))
+
+//go:embed refine.tmpl
+var refineText string
+
+var refineTemplate = template.Must(template.New("refine").Parse(refineText))
+
+//go:embed summary.tmpl
+var summaryText string
+
+var summaryTemplate = template.Must(template.New("summary").Parse(summaryText))
 
 // Generate creates a prompt for Claude to generate release notes.
-func Generate(targetTag, prevTag, commitDetails, instructions string) string {
+func Generate(data Data) string {
 	var buf bytes.Buffer
 
-	data := templateData{
-		TargetTag:     targetTag,
-		PrevTag:       prevTag,
-		CommitDetails: commitDetails,
-		Instructions:  instructions,
+	if len(data.Sections) == 0 {
+		data.Sections = DefaultSections
 	}
 
 	// Template is validated at init via template.Must; execution only fails
@@ -36,3 +178,57 @@ func Generate(targetTag, prevTag, commitDetails, instructions string) string {
 
 	return buf.String()
 }
+
+// Refine extends the original prompt with a previous draft and reviewer feedback,
+// asking for a revised version of the draft.
+func Refine(original, draft, feedback string) string {
+	var buf bytes.Buffer
+
+	_ = refineTemplate.Execute(&buf, map[string]string{
+		"Prompt":   strings.TrimRight(original, "\n"),
+		"Draft":    strings.TrimSpace(draft),
+		"Feedback": strings.TrimSpace(feedback),
+	})
+
+	return buf.String()
+}
+
+// SummaryVersion identifies the summary prompt in the summary cache. Increase it when
+// summary.tmpl changes in a way that affects the summaries, so that cached ones are not reused.
+const SummaryVersion = 3
+
+// Summarize creates a prompt asking for a one-line summary of each commit of one batch
+// of a release, whose notes are then written from the summaries.
+func Summarize(data BatchData) string {
+	var buf bytes.Buffer
+
+	_ = summaryTemplate.Execute(&buf, data)
+
+	return buf.String()
+}
+
+// charsPerToken is the average length of a token in English text and code.
+const charsPerToken = 4
+
+// EstimateTokens returns a rough estimate of the number of tokens in text. It is good
+// enough to tell whether a prompt fits a context window, not to count tokens exactly.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// summaryLine matches a line of the response to a summary prompt: "- <hash>: <summary>".
+var summaryLine = regexp.MustCompile("^\\s*[-*]\\s+`?([0-9a-f]{7,40})`?\\s*:\\s*(.+)$")
+
+// ParseSummaries returns the summaries of the response to a summary prompt by commit
+// hash, as given in the response. Lines in another form are ignored.
+func ParseSummaries(response string) map[string]string {
+	summaries := map[string]string{}
+
+	for line := range strings.SplitSeq(response, "\n") {
+		if m := summaryLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			summaries[m[1]] = strings.TrimSpace(m[2])
+		}
+	}
+
+	return summaries
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func TestGenerate_with_prev_tag(t *testing.T) {
-	got := Generate("v2.0", "v1.0", "commit details here", "")
+	got := Generate(Data{TargetTag: "v2.0", PrevTag: "v1.0", CommitDetails: "commit details here", Tools: true})
 
 	if !strings.Contains(got, "version v2.0") {
 		t.Error("missing target tag in header")
@@ -22,7 +22,7 @@ func TestGenerate_with_prev_tag(t *testing.T) {
 }
 
 func TestGenerate_without_prev_tag(t *testing.T) {
-	got := Generate("v1.0", "", "commit details here", "")
+	got := Generate(Data{TargetTag: "v1.0", CommitDetails: "commit details here"})
 
 	if !strings.Contains(got, "version v1.0") {
 		t.Error("missing target tag in header")
@@ -38,7 +38,7 @@ func TestGenerate_without_prev_tag(t *testing.T) {
 }
 
 func TestGenerate_with_instructions(t *testing.T) {
-	got := Generate("v1.0", "", "commits", "Very detailed api section")
+	got := Generate(Data{TargetTag: "v1.0", CommitDetails: "commits", Instructions: "Very detailed api section"})
 
 	if !strings.Contains(got, "Custom Instructions") {
 		t.Error("missing custom instructions section")
@@ -50,9 +50,243 @@ func TestGenerate_with_instructions(t *testing.T) {
 }
 
 func TestGenerate_without_instructions(t *testing.T) {
-	got := Generate("v1.0", "", "commits", "")
+	got := Generate(Data{TargetTag: "v1.0", CommitDetails: "commits"})
 
 	if strings.Contains(got, "Custom Instructions") {
 		t.Error("should not contain custom instructions section when empty")
 	}
 }
+
+func TestGenerate_default_sections(t *testing.T) {
+	got := Generate(Data{TargetTag: "v1.0", CommitDetails: "commits"})
+
+	if !strings.Contains(got, "Group changes by: Breaking Changes, Features, Improvements, Bug Fixes") {
+		t.Error("missing default sections")
+	}
+}
+
+func TestGenerate_custom_sections(t *testing.T) {
+	got := Generate(Data{TargetTag: "v1.0", CommitDetails: "commits", Sections: []string{"Added", "Fixed"}})
+
+	if !strings.Contains(got, "Group changes by: Added, Fixed\n") {
+		t.Error("missing custom sections")
+	}
+}
+
+func TestRefine(t *testing.T) {
+	got := Refine("original prompt\n", "## Features\n\n- A", "Mention B")
+
+	for _, want := range []string{"original prompt\n\n## Previous Draft", "- A", "Reviewer Feedback", "Mention B"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestGenerate_unreleased(t *testing.T) {
+	got := Generate(Data{TargetTag: "HEAD", PrevTag: "v1.0", CommitDetails: "commits", Unreleased: true})
+
+	if !strings.HasPrefix(got, "You are composing draft release notes for the unreleased changes on HEAD since v1.0.") {
+		t.Errorf("unexpected header:\n%s", got)
+	}
+
+	if strings.Contains(got, "version HEAD") {
+		t.Error("unreleased prompt should not call the ref a version")
+	}
+}
+
+func TestGenerate_component(t *testing.T) {
+	got := Generate(Data{TargetTag: "api/v1.1.0", Component: "api", Paths: []string{"services/api/**", "libs/**"}})
+
+	if !strings.Contains(got, "only the api component") {
+		t.Error("missing component scope")
+	}
+
+	if !strings.Contains(got, "limited to: services/api/**, libs/**.") {
+		t.Error("missing component paths")
+	}
+
+	if strings.Contains(Generate(Data{TargetTag: "v1.0"}), "component") {
+		t.Error("component scope should only be present for components")
+	}
+}
+
+func TestGenerate_until(t *testing.T) {
+	got := Generate(Data{TargetTag: "v2.0", PrevTag: "v1.0", Until: "abc123", Tools: true})
+
+	if !strings.Contains(got, "The commits below cover v1.0 to abc123.") {
+		t.Error("missing commit range")
+	}
+
+	if !strings.Contains(got, "git diff v1.0^..abc123 -- <path>") {
+		t.Error("cheat sheet should diff up to the --until ref")
+	}
+
+	if strings.Contains(Generate(Data{TargetTag: "v2.0", PrevTag: "v1.0"}), "commits below cover") {
+		t.Error("commit range should only be described with --until")
+	}
+}
+
+func TestGenerate_conventional_changes(t *testing.T) {
+	got := Generate(Data{TargetTag: "v2.0", Changes: []Change{
+		{Hash: "aaa1111222", Type: "fix", Description: "handle empty body"},
+		{Hash: "bbb1111222", Type: "wip", Description: "experiment"},
+		{Hash: "ccc1111222", Type: "feat", Scope: "api", Description: "add export"},
+	}})
+
+	fixes := strings.Index(got, "### Bug Fixes (fix)")
+	features := strings.Index(got, "### Features (feat)")
+	other := strings.Index(got, "### wip")
+
+	if features < 0 || fixes < features || other < fixes {
+		t.Errorf("groups missing or out of order:\n%s", got)
+	}
+
+	if !strings.Contains(got, "- ccc1111 **api:** add export") {
+		t.Error("missing classified change")
+	}
+
+	if strings.Contains(got, "breaking change") {
+		t.Error("breaking changes should only be mentioned when present")
+	}
+
+	if strings.Contains(Generate(Data{TargetTag: "v2.0"}), "## Conventional Commits") {
+		t.Error("classification should only be present for conventional commits")
+	}
+}
+
+func TestGenerate_breaking_changes(t *testing.T) {
+	got := Generate(Data{TargetTag: "v2.0", Changes: []Change{
+		{Hash: "aaa1111222", Type: "feat", Description: "drop v1 API", Breaking: true, BreakingNote: "use /v2"},
+		{Hash: "bbb2222333", Description: "Remove the legacy config", Breaking: true},
+	}})
+
+	if !strings.Contains(got, "- bbb2222 Remove the legacy config\n") {
+		t.Errorf("breaking change without a type not listed:\n%s", got)
+	}
+
+	if strings.Count(got, "bbb2222") != 1 || strings.Contains(got, "### \n") {
+		t.Error("a change without a type should not be grouped by type")
+	}
+
+	if !strings.Contains(got, "- aaa1111 drop v1 API (breaking)") {
+		t.Error("breaking change not marked in its group")
+	}
+
+	if !strings.Contains(got, "MUST be described") || !strings.Contains(got, "  BREAKING CHANGE: use /v2") {
+		t.Errorf("missing breaking changes block:\n%s", got)
+	}
+
+	if !strings.Contains(got, "- Never leave out a breaking change listed above") {
+		t.Error("missing breaking change instruction")
+	}
+}
+
+func TestGenerate_diffs(t *testing.T) {
+	without := Generate(Data{TargetTag: "v1.0", CommitDetails: "details"})
+	if strings.Contains(without, "Diff:") {
+		t.Error("unexpected note about diffs")
+	}
+
+	got := Generate(Data{TargetTag: "v1.0", CommitDetails: "details", Diffs: true})
+	if !strings.Contains(got, `The patches of the commits are included under "Diff:"`) ||
+		!strings.Contains(got, "- Read the diffs") {
+		t.Errorf("missing note about diffs:\n%s", got)
+	}
+}
+
+func TestGenerate_without_tools(t *testing.T) {
+	got := Generate(Data{TargetTag: "v2.0", PrevTag: "v1.0", CommitDetails: "details", Diffs: true})
+
+	for _, unwanted := range []string{"Cheat-Sheet", "git show", "git commands"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("unexpected %q in a prompt for a model without tools", unwanted)
+		}
+	}
+
+	if !strings.Contains(got, "rely only on the text above") {
+		t.Errorf("missing instruction to rely on the prompt:\n%s", got)
+	}
+
+	tools := Generate(Data{TargetTag: "v2.0", CommitDetails: "details", Tools: true})
+	if strings.Contains(tools, "rely only") {
+		t.Error("a model with tools should be told to explore")
+	}
+}
+
+func TestGenerate_with_summaries(t *testing.T) {
+	got := Generate(Data{
+		TargetTag:     "v2.0",
+		CommitDetails: "commit details here",
+		Summaries: []Summary{
+			{Hash: "abc1234def", Text: "Added export (#12)"},
+			{Hash: "fed9876cba", Text: "internal: bump deps"},
+		},
+		Changes: []Change{
+			{Hash: "abc1234def", Type: "feat", Description: "add export"},
+			{Hash: "def5678abc", Type: "feat", Description: "drop v1 API", Breaking: true},
+		},
+	})
+
+	for _, want := range []string{"## Commit Summaries", "- abc1234 Added export (#12)\n- fed9876 internal: bump deps"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	if strings.Contains(got, "commit details here") || strings.Contains(got, "## Conventional Commits") {
+		t.Error("summaries should replace the commit details and their classification")
+	}
+
+	if !strings.Contains(got, "def5678 drop v1 API") {
+		t.Error("breaking changes should still be listed")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	got := Summarize(BatchData{TargetTag: "v2.0", Component: "api", Batch: 2, Batches: 3, CommitDetails: "commits"})
+
+	if single := Summarize(BatchData{TargetTag: "v2.0", Batch: 1, Batches: 1}); strings.Contains(single, "batch") {
+		t.Errorf("a single batch should not be numbered:\n%s", single)
+	}
+
+	for _, want := range []string{"release notes for v2.0 of the api component", "below is batch 2", "3 batches", "- <short hash>: <summary>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{strings.Repeat("ü", 8), 2},
+	}
+
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseSummaries(t *testing.T) {
+	got := ParseSummaries("Here you go:\n\n- abc1234: Added export (#12)\n* `def5678` : internal: bump deps\n- not a hash: x\n")
+
+	want := map[string]string{"abc1234": "Added export (#12)", "def5678": "internal: bump deps"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for hash, text := range want {
+		if got[hash] != text {
+			t.Errorf("summary of %s = %q, want %q", hash, got[hash], text)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package retry repeats operations that fail with transient errors, such as rate
+// limiting, server overload or a dropped connection, with jittered exponential backoff.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy controls how often and how long Do waits between attempts.
+type Policy struct {
+	// Attempts is the maximum number of calls, including the first one.
+	Attempts int
+	// BaseDelay is the wait before the first retry; it doubles with every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps a single wait, including one requested by the server.
+	MaxDelay time.Duration
+	// OnRetry, if set, is called before every wait, e.g. to tell the user.
+	OnRetry func(err error, wait time.Duration)
+}
+
+// Error marks an error as transient: the operation may succeed if repeated.
+type Error struct {
+	Err error
+	// After is the wait requested by the server (Retry-After), or 0 to use the backoff.
+	After time.Duration
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Retryable marks err as transient, with the wait requested by the server, if any.
+// A nil err stays nil.
+func Retryable(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Err: err, After: after}
+}
+
+// IsRetryable reports whether err, or any error it wraps, is marked as transient.
+// Errors that are not marked are fatal.
+func IsRetryable(err error) bool {
+	var e *Error
+
+	return errors.As(err, &e)
+}
+
+// Do calls fn until it succeeds, fails with an error not marked as transient, ctx is
+// done or the attempts of the policy run out. It returns the error of the last call.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+
+		var e *Error
+		if err == nil || attempt >= p.Attempts || ctx.Err() != nil || !errors.As(err, &e) {
+			return err
+		}
+
+		wait := p.delay(attempt)
+		if e.After > 0 {
+			wait = min(e.After, p.MaxDelay)
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(err, wait)
+		}
+
+		if sleepErr := Sleep(ctx, wait); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// delay returns the wait before retry n (starting at 1): BaseDelay doubled n-1 times,
+// capped at MaxDelay, with up to half of it taken off at random so that clients that
+// failed together do not retry together.
+func (p Policy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+
+	d = min(d, p.MaxDelay)
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + rand.N(d/2+1) //nolint:gosec // jitter does not need a secure source
+}
+
+// Sleep waits for d, returning early with the context error when ctx is done.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ParseRetryAfter returns the wait requested by a Retry-After header, given either as
+// seconds or as an HTTP date, or 0 if the header is missing or invalid.
+func ParseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if t, err := http.ParseTime(header); err == nil {
+		return max(t.Sub(now), 0)
+	}
+
+	return 0
+}
+
+// StatusOverloaded is the non-standard status Anthropic returns when its API is overloaded.
+const StatusOverloaded = 529
+
+// IsRetryableStatus reports whether an HTTP status is worth retrying: rate limiting,
+// request timeouts, server errors and overload.
+func IsRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout, StatusOverloaded:
+		return true
+	default:
+		return false
+	}
+}
+
+// ForResponse marks err, made from a failed HTTP response, as transient if the status of
+// resp is worth retrying, honoring the wait requested by its Retry-After header.
+func ForResponse(err error, resp *http.Response) error {
+	if !IsRetryableStatus(resp.StatusCode) {
+		return err
+	}
+
+	return Retryable(err, ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+}
+
+// transientMessage matches error output of command-line tools that talk to HTTP APIs
+// (gh, claude) describing rate limiting, overload or network failures.
+var transientMessage = regexp.MustCompile(`(?i)\b(?:408|429|500|502|503|504|529)\b|rate[ _-]?limit|` +
+	`overloaded|temporarily unavailable|timed out|connection (?:reset|refused)|` +
+	`ECONNRESET|ETIMEDOUT|EAI_AGAIN|socket hang up`)
+
+// IsTransientMessage reports whether the error output of a command describes a
+// transient failure, for tools that only report errors as text.
+func IsTransientMessage(msg string) bool {
+	return transientMessage.MatchString(msg)
+}
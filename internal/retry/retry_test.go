@@ -0,0 +1,208 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fastPolicy retries without noticeable waits.
+var fastPolicy = Policy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+func TestDo_retries_transient_errors(t *testing.T) {
+	calls := 0
+
+	err := Do(t.Context(), fastPolicy, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return Retryable(fmt.Errorf("overloaded"), 0)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+}
+
+func TestDo_stops_on_fatal_error(t *testing.T) {
+	calls := 0
+
+	err := Do(t.Context(), fastPolicy, func(context.Context) error {
+		calls++
+
+		return fmt.Errorf("invalid API key")
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if calls != 1 {
+		t.Errorf("got %d calls, want 1", calls)
+	}
+}
+
+func TestDo_gives_up_after_attempts(t *testing.T) {
+	calls := 0
+
+	var waits []time.Duration
+
+	p := fastPolicy
+	p.OnRetry = func(_ error, wait time.Duration) { waits = append(waits, wait) }
+
+	err := Do(t.Context(), p, func(context.Context) error {
+		calls++
+
+		return Retryable(fmt.Errorf("rate limited"), 0)
+	})
+	if !IsRetryable(err) {
+		t.Fatalf("got %v, want the last transient error", err)
+	}
+
+	if calls != 3 || len(waits) != 2 {
+		t.Errorf("got %d calls and %d waits, want 3 and 2", calls, len(waits))
+	}
+}
+
+func TestDo_honors_retry_after(t *testing.T) {
+	var waits []time.Duration
+
+	p := Policy{Attempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	p.OnRetry = func(_ error, wait time.Duration) { waits = append(waits, wait) }
+
+	_ = Do(t.Context(), p, func(context.Context) error {
+		return Retryable(fmt.Errorf("rate limited"), time.Hour)
+	})
+
+	// The requested wait is capped at MaxDelay
+	if len(waits) != 1 || waits[0] != 5*time.Millisecond {
+		t.Errorf("got waits %v, want [5ms]", waits)
+	}
+}
+
+func TestDo_stops_when_context_is_done(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	calls := 0
+
+	p := Policy{Attempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	p.OnRetry = func(error, time.Duration) { cancel() }
+
+	err := Do(ctx, p, func(context.Context) error {
+		calls++
+
+		return Retryable(fmt.Errorf("overloaded"), 0)
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if calls != 1 {
+		t.Errorf("got %d calls, want 1", calls)
+	}
+}
+
+func TestPolicy_delay(t *testing.T) {
+	p := Policy{BaseDelay: 2 * time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{1, time.Second, 2 * time.Second},
+		{2, 2 * time.Second, 4 * time.Second},
+		{3, 4 * time.Second, 8 * time.Second},
+		{4, 5 * time.Second, 10 * time.Second},
+		{40, 5 * time.Second, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			if d := p.delay(tt.retry); d < tt.min || d > tt.max {
+				t.Errorf("delay(%d) = %v, want between %v and %v", tt.retry, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestIsRetryable_wrapped(t *testing.T) {
+	err := fmt.Errorf("generate: %w", Retryable(fmt.Errorf("overloaded"), 0))
+	if !IsRetryable(err) {
+		t.Error("expected wrapped transient error to be retryable")
+	}
+
+	if IsRetryable(fmt.Errorf("not found")) {
+		t.Error("expected unmarked error to be fatal")
+	}
+
+	if Retryable(nil, 0) != nil {
+		t.Error("expected nil error to stay nil")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{" 5 ", 5 * time.Second},
+		{"-1", 0},
+		{"Thu, 02 Jan 2025 03:05:05 GMT", time.Minute},
+		{"Thu, 02 Jan 2025 03:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestForResponse(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"7"}}}
+
+	err := ForResponse(fmt.Errorf("429 Too Many Requests"), resp)
+
+	e, ok := err.(*Error)
+	if !ok || e.After != 7*time.Second {
+		t.Errorf("got %#v, want a transient error waiting 7s", err)
+	}
+
+	resp = &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}}
+	if IsRetryable(ForResponse(fmt.Errorf("401 Unauthorized"), resp)) {
+		t.Error("expected 401 to be fatal")
+	}
+}
+
+func TestIsTransientMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"non-200 OK status code: 429 Too Many Requests body: ...", true},
+		{"HTTP 502: Bad Gateway (https://api.github.com/graphql)", true},
+		{`API Error: 529 {"type":"error","error":{"type":"overloaded_error"}}`, true},
+		{"API Error: Rate limit reached", true},
+		{"read ECONNRESET", true},
+		{"release not found", false},
+		{"HTTP 404: Not Found", false},
+		{"processed 5000 commits", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransientMessage(tt.msg); got != tt.want {
+			t.Errorf("IsTransientMessage(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package semver parses and compares semantic versions as used in release tags.
+package semver
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      string
+}
+
+// Parse parses a version such as "v1.2.3", "1.2.3-rc.1+build.5" or "v1.2".
+// A leading "v" is optional; missing minor and patch components default to zero.
+// Returns false if s is not a version.
+func Parse(s string) (Version, bool) {
+	var v Version
+
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+
+	s, v.Build, _ = strings.Cut(s, "+")
+
+	core, pre, hasPre := strings.Cut(s, "-")
+	if hasPre {
+		if pre == "" {
+			return Version{}, false
+		}
+
+		v.Prerelease = strings.Split(pre, ".")
+		for _, id := range v.Prerelease {
+			if id == "" {
+				return Version{}, false
+			}
+		}
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return Version{}, false
+	}
+
+	nums := [3]int{}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || strings.HasPrefix(p, "+") {
+			return Version{}, false
+		}
+
+		nums[i] = n
+	}
+
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	return v, true
+}
+
+// IsPrerelease reports whether v has prerelease identifiers (e.g. "-rc.1").
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// String formats v without a "v" prefix.
+func (v Version) String() string {
+	var b strings.Builder
+
+	b.WriteString(strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch))
+
+	if v.IsPrerelease() {
+		b.WriteString("-" + strings.Join(v.Prerelease, "."))
+	}
+
+	if v.Build != "" {
+		b.WriteString("+" + v.Build)
+	}
+
+	return b.String()
+}
+
+// Compare returns -1, 0 or 1 depending on whether a precedes, equals or follows b
+// according to semver precedence. Build metadata is ignored.
+func Compare(a, b Version) int {
+	if c := compareInt(a.Major, b.Major); c != 0 {
+		return c
+	}
+
+	if c := compareInt(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+
+	if c := compareInt(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+
+	return comparePrerelease(a.Prerelease, b.Prerelease)
+}
+
+// comparePrerelease compares prerelease identifiers; a version without them ranks higher.
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareInt(len(a), len(b))
+}
+
+// compareIdentifier compares numeric identifiers numerically and others lexically;
+// numeric identifiers rank lower than alphanumeric ones.
+func compareIdentifier(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		return compareInt(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
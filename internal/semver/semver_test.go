@@ -0,0 +1,73 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"v1.2.3", "1.2.3"},
+		{"1.2.3", "1.2.3"},
+		{"v1.2", "1.2.0"},
+		{"v2", "2.0.0"},
+		{"v2.0.0-rc.1", "2.0.0-rc.1"},
+		{"1.0.0-beta+exp.sha.5114f85", "1.0.0-beta+exp.sha.5114f85"},
+	}
+
+	for _, tt := range tests {
+		v, ok := Parse(tt.in)
+		if !ok {
+			t.Errorf("Parse(%q) failed", tt.in)
+
+			continue
+		}
+
+		if got := v.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	for _, in := range []string{"", "latest", "v1.2.3.4", "v1.x", "v1.2.3-", "v1.2.3-rc..1", "v-1.0.0", "v+1.0"} {
+		if _, ok := Parse(in); ok {
+			t.Errorf("Parse(%q) succeeded, want failure", in)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Ordered by increasing precedence (semver.org example plus core versions)
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "1.10.0", "2.0.0",
+	}
+
+	for i := 0; i+1 < len(ordered); i++ {
+		a, _ := Parse(ordered[i])
+		b, _ := Parse(ordered[i+1])
+
+		if Compare(a, b) != -1 || Compare(b, a) != 1 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+}
+
+func TestCompare_ignores_build(t *testing.T) {
+	a, _ := Parse("1.0.0+a")
+	b, _ := Parse("v1.0.0+b")
+
+	if Compare(a, b) != 0 {
+		t.Error("expected build metadata to be ignored")
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	rc, _ := Parse("v2.0.0-rc.3")
+	stable, _ := Parse("v2.0.0")
+
+	if !rc.IsPrerelease() || stable.IsPrerelease() {
+		t.Error("unexpected IsPrerelease result")
+	}
+}
@@ -36,6 +36,9 @@ func Bold(s string) string { return apply("1", s) }
 // Dim returns s with dim formatting.
 func Dim(s string) string { return apply("2", s) }
 
+// Red returns s colored red.
+func Red(s string) string { return apply("31", s) }
+
 // Green returns s colored green.
 func Green(s string) string { return apply("32", s) }
 